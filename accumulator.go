@@ -0,0 +1,85 @@
+package multiexp
+
+import (
+	"context"
+	"math/big"
+	"sync"
+)
+
+// ProductAccumulator accumulates the product of g**e mod m over the exponents e passed to Add.
+// It is safe for concurrent use: the exponentiations run outside the lock,
+// which is only taken for the final multiplication into the product.
+type ProductAccumulator struct {
+	Base    *big.Int
+	Modulus *big.Int
+	mu      sync.Mutex
+	prod    *big.Int
+}
+
+// NewProductAccumulator creates an accumulator of the powers of g mod m, with the empty product 1.
+// It returns nil if g is nil or if m is nil, not greater than 1 or even.
+func NewProductAccumulator(g, m *big.Int) *ProductAccumulator {
+	if g == nil || m == nil || m.Cmp(big1) <= 0 || m.Bit(0) != 1 {
+		return nil
+	}
+	return &ProductAccumulator{
+		Base:    new(big.Int).Set(g),
+		Modulus: new(big.Int).Set(m),
+		prod:    big.NewInt(1),
+	}
+}
+
+// Add multiplies the product by g**e mod m. Add panics if e is negative.
+func (a *ProductAccumulator) Add(e *big.Int) {
+	// never canceled, so there is no error
+	_ = a.AddContext(context.Background(), e)
+}
+
+// AddContext is like Add but aborts the exponentiation when ctx is done, leaving the product unchanged
+// and returning ctx.Err(). ctx is checked once per exponent word.
+func (a *ProductAccumulator) AddContext(ctx context.Context, e *big.Int) error {
+	if e.Sign() < 0 {
+		panic("invalid e: negative value")
+	}
+	var z *big.Int
+	if a.Base.Cmp(big1) <= 0 || e.Sign() == 0 {
+		z = new(big.Int).Exp(a.Base, e, a.Modulus)
+	} else {
+		zWords, err := expNNMontgomeryContext(ctx, newNat(a.Base), newNat(e), newNat(a.Modulus))
+		if err != nil {
+			return err
+		}
+		z = new(big.Int).SetBits(zWords.intBits())
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.prod.Mul(a.prod, z)
+	a.prod.Mod(a.prod, a.Modulus)
+	return nil
+}
+
+// Value returns the current product.
+func (a *ProductAccumulator) Value() *big.Int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return new(big.Int).Set(a.prod)
+}
+
+// expNNMontgomeryContext calculates x**y mod m like expNNMontgomery, returning ctx.Err() as soon as
+// ctx is done. ctx is checked before each word of y.
+func expNNMontgomeryContext(ctx context.Context, x, y, m nat) (nat, error) {
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	yList := []nat{y}
+	zList := []nat{nat(nil).set(power0)}
+	squaredPower := power1
+	temp := nat(nil).make(numWords)
+	topBit := maxBitLen(yList)
+	for i := 0; i < (topBit+_W-1)/_W; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		squaredPower, temp = multiMontgomeryWord(m, k0, numWords, yList, zList, squaredPower, temp, i, topBit)
+	}
+	return assembleAndConvert(zList[0], nil, m, k0, numWords).norm(), nil
+}
@@ -0,0 +1,53 @@
+package multiexp
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func TestProductAccumulator(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	es := append(getDifferentBenchParameters(4), big.NewInt(0), big.NewInt(1))
+	acc := NewProductAccumulator(g, n)
+	var wg sync.WaitGroup
+	for _, e := range es {
+		wg.Add(1)
+		go func(e *big.Int) {
+			defer wg.Done()
+			acc.Add(e)
+		}(e)
+	}
+	wg.Wait()
+
+	sum := new(big.Int)
+	for _, e := range es {
+		sum.Add(sum, e)
+	}
+	want := new(big.Int).Exp(g, sum, n)
+	if got := acc.Value(); got.Cmp(want) != 0 {
+		t.Errorf("Wrong product for ProductAccumulator")
+	}
+
+	// a canceled Add leaves the product unchanged
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := acc.AddContext(ctx, es[0]); !errors.Is(err, context.Canceled) {
+		t.Errorf("AddContext returned %v, want context.Canceled", err)
+	}
+	if got := acc.Value(); got.Cmp(want) != 0 {
+		t.Errorf("a canceled AddContext changed the product")
+	}
+	if err := acc.AddContext(context.Background(), es[1]); err != nil {
+		t.Errorf("AddContext returned %v", err)
+	}
+	want.Mul(want, new(big.Int).Exp(g, es[1], n)).Mod(want, n)
+	if got := acc.Value(); got.Cmp(want) != 0 {
+		t.Errorf("Wrong product for ProductAccumulator after AddContext")
+	}
+	if NewProductAccumulator(g, big.NewInt(10)) != nil {
+		t.Errorf("NewProductAccumulator accepted an even modulus")
+	}
+}
@@ -2,8 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-//go:build math_big_pure_go
-// +build math_big_pure_go
+//go:build math_big_pure_go || !(386 || amd64 || arm || arm64 || mips || mipsle || mips64 || mips64le || ppc64 || ppc64le || riscv64 || s390x)
+// +build math_big_pure_go !386,!amd64,!arm,!arm64,!mips,!mipsle,!mips64,!mips64le,!ppc64,!ppc64le,!riscv64,!s390x
 
 package multiexp
 
@@ -1,5 +1,7 @@
 // Trampolines to math/big assembly implementations.
 
+//go:build !math_big_pure_go && (ppc64 || ppc64le)
+// +build !math_big_pure_go
 // +build ppc64 ppc64le
 
 #include "textflag.h"
@@ -24,10 +26,6 @@ TEXT ·subVW(SB),NOSPLIT,$0
 TEXT ·shlVU(SB),NOSPLIT,$0
 	BR	math∕big·shlVU(SB)
 
-// func shrVU(z, x []Word, s uint) (c Word)
-TEXT ·shrVU(SB),NOSPLIT,$0
-	BR	math∕big·shrVU(SB)
-
 // func mulAddVWW(z, x []Word, y, r Word) (c Word)
 TEXT ·mulAddVWW(SB),NOSPLIT,$0
 	BR	math∕big·mulAddVWW(SB)
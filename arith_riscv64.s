@@ -1,5 +1,7 @@
 // Trampolines to math/big assembly implementations.
 
+//go:build !math_big_pure_go && (riscv || riscv64)
+// +build !math_big_pure_go
 // +build riscv riscv64
 
 #include "textflag.h"
@@ -26,10 +28,6 @@ TEXT ·subVW(SB),NOSPLIT,$0
 TEXT ·shlVU(SB),NOSPLIT,$0
 	JMP	math∕big·shlVU(SB)
 
-// func shrVU(z, x []Word, s uint) (c Word)
-TEXT ·shrVU(SB),NOSPLIT,$0
-	JMP	math∕big·shrVU(SB)
-
 // func mulAddVWW(z, x []Word, y, r Word) (c Word)
 TEXT ·mulAddVWW(SB),NOSPLIT,$0
 	JMP	math∕big·mulAddVWW(SB)
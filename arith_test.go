@@ -0,0 +1,156 @@
+package multiexp
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+var arithTestLens = []int{0, 1, 2, 3, 7, 16, 33, 100}
+
+func randWords(t *testing.T, n int) []Word {
+	z := make([]Word, n)
+	for i := range z {
+		w, err := rand.Int(rand.Reader, new(big.Int).Lsh(big1, _W))
+		if err != nil {
+			t.Fatal(err)
+		}
+		z[i] = Word(w.Uint64())
+	}
+	return z
+}
+
+// wordsToInt converts the words and a top carry word into a big.Int.
+func wordsToInt(z []Word, c Word) *big.Int {
+	words := make([]big.Word, len(z)+1)
+	for i, d := range z {
+		words[i] = big.Word(d)
+	}
+	words[len(z)] = big.Word(c)
+	return new(big.Int).SetBits(words)
+}
+
+func TestAddMulVVW(t *testing.T) {
+	for _, n := range arithTestLens {
+		for _, fn := range []struct {
+			name string
+			f    func(z, x []Word, y Word) Word
+		}{{"addMulVVW", addMulVVW}, {"addMulVVW_g", addMulVVW_g}} {
+			z, x, y := randWords(t, n), randWords(t, n), randWords(t, 1)[0]
+			// naive reference: z + x*y
+			want := new(big.Int).Mul(wordsToInt(x, 0), new(big.Int).SetUint64(uint64(y)))
+			want.Add(want, wordsToInt(z, 0))
+			c := fn.f(z, x, y)
+			if got := wordsToInt(z, c); got.Cmp(want) != 0 {
+				t.Errorf("%s, len = %d: got %v, want %v", fn.name, n, got, want)
+			}
+		}
+	}
+}
+
+func TestMulAddVWW(t *testing.T) {
+	for _, n := range arithTestLens {
+		for _, fn := range []struct {
+			name string
+			f    func(z, x []Word, y, r Word) Word
+		}{{"mulAddVWW", mulAddVWW}, {"mulAddVWW_g", mulAddVWW_g}} {
+			z, x, yr := make([]Word, n), randWords(t, n), randWords(t, 2)
+			// naive reference: x*y + r
+			want := new(big.Int).Mul(wordsToInt(x, 0), new(big.Int).SetUint64(uint64(yr[0])))
+			want.Add(want, new(big.Int).SetUint64(uint64(yr[1])))
+			c := fn.f(z, x, yr[0], yr[1])
+			if got := wordsToInt(z, c); got.Cmp(want) != 0 {
+				t.Errorf("%s, len = %d: got %v, want %v", fn.name, n, got, want)
+			}
+		}
+	}
+}
+
+func TestAddSubVV(t *testing.T) {
+	for _, n := range arithTestLens {
+		x, y := randWords(t, n), randWords(t, n)
+		xInt, yInt := wordsToInt(x, 0), wordsToInt(y, 0)
+
+		for _, f := range []func(z, x, y []Word) Word{addVV, addVV_g} {
+			z := make([]Word, n)
+			c := f(z, x, y)
+			if got, want := wordsToInt(z, c), new(big.Int).Add(xInt, yInt); got.Cmp(want) != 0 {
+				t.Errorf("addVV, len = %d: got %v, want %v", n, got, want)
+			}
+		}
+		for _, f := range []func(z, x, y []Word) Word{subVV, subVV_g} {
+			z := make([]Word, n)
+			c := f(z, x, y)
+			// x - y + c*2^(n*_W) must equal z
+			got := new(big.Int).Sub(wordsToInt(z, 0), new(big.Int).Lsh(new(big.Int).SetUint64(uint64(c)), uint(n*_W)))
+			if want := new(big.Int).Sub(xInt, yInt); got.Cmp(want) != 0 {
+				t.Errorf("subVV, len = %d: got %v, want %v", n, got, want)
+			}
+		}
+	}
+}
+
+func TestAndVV(t *testing.T) {
+	for _, n := range arithTestLens {
+		// x is one word longer, only the words of z are read
+		x, y := randWords(t, n+1), randWords(t, n)
+		z := make([]Word, n)
+		andVV(z, x, y)
+		want := new(big.Int).And(wordsToInt(x[:n], 0), wordsToInt(y, 0))
+		if got := wordsToInt(z, 0); got.Cmp(want) != 0 {
+			t.Errorf("andVV, len = %d: got %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestAddSubVW(t *testing.T) {
+	for _, n := range arithTestLens {
+		if n == 0 {
+			continue
+		}
+		x, y := randWords(t, n), randWords(t, 1)[0]
+		xInt, yInt := wordsToInt(x, 0), new(big.Int).SetUint64(uint64(y))
+
+		for _, f := range []func(z, x []Word, y Word) Word{addVW, addVW_g, addVWlarge} {
+			z := make([]Word, n)
+			c := f(z, x, y)
+			if got, want := wordsToInt(z, c), new(big.Int).Add(xInt, yInt); got.Cmp(want) != 0 {
+				t.Errorf("addVW, len = %d: got %v, want %v", n, got, want)
+			}
+		}
+		for _, f := range []func(z, x []Word, y Word) Word{subVW, subVW_g, subVWlarge} {
+			z := make([]Word, n)
+			c := f(z, x, y)
+			got := new(big.Int).Sub(wordsToInt(z, 0), new(big.Int).Lsh(new(big.Int).SetUint64(uint64(c)), uint(n*_W)))
+			if want := new(big.Int).Sub(xInt, yInt); got.Cmp(want) != 0 {
+				t.Errorf("subVW, len = %d: got %v, want %v", n, got, want)
+			}
+		}
+	}
+}
+
+func TestShlShrVU(t *testing.T) {
+	for _, n := range arithTestLens {
+		if n == 0 {
+			continue
+		}
+		x := randWords(t, n)
+		xInt := wordsToInt(x, 0)
+		for _, s := range []uint{0, 1, 17, _W - 1} {
+			for _, f := range []func(z, x []Word, s uint) Word{shlVU, shlVU_g} {
+				z := make([]Word, n)
+				c := f(z, x, s)
+				if got, want := wordsToInt(z, c), new(big.Int).Lsh(xInt, s); got.Cmp(want) != 0 {
+					t.Errorf("shlVU, len = %d, s = %d: got %v, want %v", n, s, got, want)
+				}
+			}
+			for _, f := range []func(z, x []Word, s uint) Word{shrVU, shrVU_g} {
+				z := make([]Word, n)
+				f(z, x, s)
+				if got, want := wordsToInt(z, 0), new(big.Int).Rsh(xInt, s); got.Cmp(want) != 0 {
+					t.Errorf("shrVU, len = %d, s = %d: got %v, want %v", n, s, got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// BasePowers holds the powers g**(2**i) of a fixed base as plain integers, i.e., without any modulus,
+// so that they can be shared among exponentiations modulo different moduli.
+//
+// Unlike a PreTable, which holds every needed power reduced mod a single modulus, the i-th plain power
+// has 2**i times as many bits as g, so only the first few squarings can be stored: g**(2**16) already
+// takes 65536 times the size of g, and NewBasePowers caps the largest power at maxBasePowerBits. ExpReducePerModulus reduces the stored powers mod m on demand,
+// which is cheaper than the corresponding squarings only while the powers are not much larger than m,
+// and continues with ordinary squarings above NumBits. Prefer a PreTable per modulus whenever the moduli
+// are known and reused; BasePowers is for many short-lived moduli with a fixed base.
+type BasePowers struct {
+	Base   *big.Int
+	powers []nat
+}
+
+// maxBasePowerBits is the largest bit length of a power stored by NewBasePowers, i.e., 8 MiB per power.
+const maxBasePowerBits = 1 << 26
+
+// NewBasePowers computes g**(2**i) for 0 <= i < numBits.
+// It returns nil for invalid inputs, and if the last power would exceed maxBasePowerBits bits,
+// which happens long before numBits reaches the bit length of typical exponents.
+func NewBasePowers(g *big.Int, numBits int) *BasePowers {
+	if g == nil || g.Sign() <= 0 || numBits <= 0 {
+		return nil
+	}
+	// the last power g**(2**(numBits-1)) has about g.BitLen() << (numBits-1) bits
+	for bits, i := g.BitLen(), 1; i < numBits; i++ {
+		if bits <<= 1; bits > maxBasePowerBits {
+			return nil
+		}
+	}
+	powers := make([]nat, numBits)
+	powers[0] = newNat(g)
+	for i := 1; i < numBits; i++ {
+		powers[i] = nat(nil).mul(powers[i-1], powers[i-1])
+	}
+	return &BasePowers{
+		Base:   new(big.Int).Set(g),
+		powers: powers,
+	}
+}
+
+// NumBits returns the number of stored powers, i.e., the number of squarings saved per modulus.
+func (b *BasePowers) NumBits() int {
+	return len(b.powers)
+}
+
+// ExpReducePerModulus computes g**y mod |m| for the base g of basePowers. A nil y is treated as 0.
+// ExpReducePerModulus is not a cryptographically constant-time operation.
+func ExpReducePerModulus(basePowers *BasePowers, y, m *big.Int) *big.Int {
+	if basePowers == nil {
+		panic("base powers is nil")
+	}
+	x := basePowers.Base
+	y = exponentOrZero(y)
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return new(big.Int).Exp(x, y, m)
+	}
+	zWords := basePowers.expNN(newNat(y), newNat(m))
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// expNN calculates g**y mod m, reducing the stored powers mod m for the low bits of y
+// and squaring the last of them mod m for the others.
+func (b *BasePowers) expNN(y, m nat) nat {
+	k0, RR, numWords := montgomeryConstants(m)
+	power0 := power0Of(RR, m, k0, numWords)
+
+	z := nat(nil).make(numWords)
+	copy(z, power0)
+	var squaredPower nat
+	temp := nat(nil).make(numWords)
+	bitLen := len(y)*_W - int(nlz(y[len(y)-1]))
+	numBits := len(b.powers)
+	for i := 0; i < bitLen; i++ {
+		if i < numBits {
+			if y[i/_W]&masks[i%_W] == 0 && i != numBits-1 {
+				continue
+			}
+			// the montgomery form of g**(2**i) mod m, the last one is kept for the squarings
+			_, squaredPower = montgomeryPowers(b.powers[i], m, k0, RR, numWords)
+		} else {
+			temp = temp.montgomery(squaredPower, squaredPower, m, k0, numWords)
+			squaredPower, temp = temp, squaredPower
+		}
+		if y[i/_W]&masks[i%_W] == 0 {
+			continue
+		}
+		temp = temp.montgomery(z, squaredPower, m, k0, numWords)
+		z, temp = temp, z
+	}
+	return assembleAndConvert(z, nil, m, k0, numWords).norm()
+}
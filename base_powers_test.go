@@ -0,0 +1,42 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestExpReducePerModulus(t *testing.T) {
+	_, _, xList := getBenchParameters(1)
+	g := big.NewInt(0).SetUint64(0xfedcba9876543211)
+	for _, numBits := range []int{1, 5, 12} {
+		basePowers := NewBasePowers(g, numBits)
+		if basePowers.NumBits() != numBits {
+			t.Errorf("NumBits = %d, want %d", basePowers.NumBits(), numBits)
+		}
+		for _, m := range []*big.Int{getPrime256(), getPrime256(), big.NewInt(1000003)} {
+			for _, y := range []*big.Int{xList[0], big.NewInt(1), big.NewInt(6), big.NewInt(0)} {
+				if got, want := ExpReducePerModulus(basePowers, y, m), new(big.Int).Exp(g, y, m); got.Cmp(want) != 0 {
+					t.Errorf("Wrong result for ExpReducePerModulus with %d stored powers", numBits)
+				}
+			}
+		}
+	}
+	// the base is copied, so changing the caller's value afterwards leaves the stored powers consistent
+	caller := new(big.Int).Set(g)
+	basePowers := NewBasePowers(caller, 5)
+	caller.SetInt64(3)
+	m := getPrime256()
+	if got, want := ExpReducePerModulus(basePowers, xList[0], m), new(big.Int).Exp(g, xList[0], m); got.Cmp(want) != 0 {
+		t.Errorf("Wrong result for ExpReducePerModulus after changing the caller's base")
+	}
+	if NewBasePowers(g, 0) != nil {
+		t.Errorf("NewBasePowers accepted no powers")
+	}
+	// 64 << 255 bits would never fit, and 64 << 21 is just above the cap
+	if NewBasePowers(g, 256) != nil || NewBasePowers(g, 22) != nil {
+		t.Errorf("NewBasePowers accepted powers above maxBasePowerBits")
+	}
+	if got := ExpReducePerModulus(basePowers, nil, m); got.Cmp(big1) != 0 {
+		t.Errorf("ExpReducePerModulus with a nil exponent = %v, want 1", got)
+	}
+}
@@ -0,0 +1,43 @@
+package multiexp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// BatchExpFromReader reads one exponent per line from r, parsed in the given base like big.Int.SetString
+// (base 0 accepts the 0x, 0o and 0b prefixes), and returns x**y mod |m| for each exponent in input order,
+// computed by BatchExp in groups of four. Surrounding spaces are trimmed and blank lines are skipped.
+// It returns an error naming the line number of the first exponent that does not parse, or the read error of r,
+// and an error without reading r if base is neither 0 nor in [2, big.MaxBase].
+//
+// BatchExpFromReader is not a cryptographically constant-time operation.
+func BatchExpFromReader(x, m *big.Int, r io.Reader, base int) ([]*big.Int, error) {
+	// SetString panics for the other bases
+	if base != 0 && (base < 2 || base > big.MaxBase) {
+		return nil, fmt.Errorf("multiexp: invalid base %d", base)
+	}
+	var ys []*big.Int
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		// ReadString has no limit on the line length, unlike bufio.Scanner
+		s, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("multiexp: line %d: %w", line, err)
+		}
+		if s = strings.TrimSpace(s); s != "" {
+			y, ok := new(big.Int).SetString(s, base)
+			if !ok {
+				return nil, fmt.Errorf("multiexp: line %d: invalid exponent in base %d", line, base)
+			}
+			ys = append(ys, y)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	return BatchExp(x, m, ys), nil
+}
@@ -0,0 +1,50 @@
+package multiexp
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBatchExpFromReader(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	// five exponents, so the second group has placeholders, with spaces, blank lines and no final newline
+	ys := []*big.Int{x4[0], x4[1], big.NewInt(0), x4[2], x4[3]}
+	input := "0x" + ys[0].Text(16) + "\n 0x" + ys[1].Text(16) + "\n\n0\n0x" + ys[3].Text(16) + "\t\n\n0x" + ys[4].Text(16)
+	got, err := BatchExpFromReader(g, n, strings.NewReader(input), 0)
+	if err != nil {
+		t.Fatalf("BatchExpFromReader: %v", err)
+	}
+	if len(got) != len(ys) {
+		t.Fatalf("BatchExpFromReader returned %d results, want %d", len(got), len(ys))
+	}
+	for i := range ys {
+		if want := new(big.Int).Exp(g, ys[i], n); got[i].Cmp(want) != 0 {
+			t.Errorf("Wrong result for exponent %d", i)
+		}
+	}
+
+	// the line of the first invalid exponent is reported
+	_, err = BatchExpFromReader(g, n, strings.NewReader("12\n\n34\n5x6\n78\n"), 10)
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("BatchExpFromReader error = %v, want an error at line 4", err)
+	}
+	// bases rejected by SetString are errors, not panics
+	for _, base := range []int{-1, 1, big.MaxBase + 1} {
+		if _, err := BatchExpFromReader(g, n, strings.NewReader("12\n"), base); err == nil {
+			t.Errorf("BatchExpFromReader accepted base %d", base)
+		}
+	}
+	// read errors are returned
+	readErr := errors.New("read failure")
+	_, err = BatchExpFromReader(g, n, iotest.ErrReader(readErr), 10)
+	if !errors.Is(err, readErr) {
+		t.Errorf("BatchExpFromReader error = %v, want %v", err, readErr)
+	}
+	if got, err := BatchExpFromReader(g, n, strings.NewReader(""), 10); err != nil || len(got) != 0 {
+		t.Errorf("BatchExpFromReader(empty) = %v, %v, want no result", got, err)
+	}
+}
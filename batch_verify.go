@@ -0,0 +1,127 @@
+package multiexp
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// defaultBatchVerifyBits is the number of random bits per triple used by BatchVerifyExp.
+const defaultBatchVerifyBits = 64
+
+// BatchVerifyExp reports whether xs[i]**ys[i] == zs[i] mod |m| for all i, with a single random linear
+// combination of the triples, see BatchVerifyExpBits. It uses 64 random bits per triple.
+func BatchVerifyExp(xs, ys, zs []*big.Int, m *big.Int) bool {
+	return BatchVerifyExpBits(xs, ys, zs, m, defaultBatchVerifyBits)
+}
+
+// BatchVerifyExpBits reports whether xs[i]**ys[i] == zs[i] mod |m| for all i. It picks random r[i] of
+// k bits and checks the product of xs[i]**(ys[i]*r[i]) against the product of zs[i]**r[i]. Each product
+// is computed in a single loop whose squarings are shared by all the terms, see interleavedMultiExp, so
+// the batch costs about one exponentiation of |y|+k bits plus a multiplication per window of each term,
+// instead of a full exponentiation per triple, see BenchmarkBatchVerifyExp. Valid triples always pass. Invalid ones pass
+// with probability at most 2**-k when the elements involved lie in a group whose order has no prime factor
+// below 2**k, e.g., a subgroup of large prime order. In Z_m* itself, a zs[i] off by a factor of small order,
+// such as -1, passes with probability up to 1/2 whatever k, so the caller must ensure the claimed results
+// are in such a subgroup (e.g., by squaring everything) where that matters.
+// It returns false if the slices have different lengths, an xs[i] or zs[i] is nil, a term is not invertible for a
+// negative exponent or the random source fails. k <= 0 means 64 bits. A nil ys[i] is treated as 0.
+// For a nil or zero m, the triples are checked one by one without reduction.
+//
+// BatchVerifyExpBits is not a cryptographically constant-time operation.
+func BatchVerifyExpBits(xs, ys, zs []*big.Int, m *big.Int, k int) bool {
+	if len(xs) != len(ys) || len(xs) != len(zs) {
+		return false
+	}
+	for i := range zs {
+		if xs[i] == nil || zs[i] == nil {
+			return false
+		}
+	}
+	if m == nil || m.Sign() == 0 {
+		for i := range xs {
+			if z := new(big.Int).Exp(xs[i], exponentOrZero(ys[i]), m); z == nil || z.Cmp(zs[i]) != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if k <= 0 {
+		k = defaultBatchVerifyBits
+	}
+
+	limit := new(big.Int).Lsh(big1, uint(k))
+	xExps, zExps := make([]*big.Int, len(xs)), make([]*big.Int, len(zs))
+	for i := range xs {
+		r, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return false
+		}
+		xExps[i] = new(big.Int).Mul(r, exponentOrZero(ys[i]))
+		zExps[i] = r
+	}
+	var left, right *big.Int
+	if m.Cmp(big1) <= 0 || m.Bit(0) != 1 {
+		left, right = MultiExp(xs, xExps, m), MultiExp(zs, zExps, m)
+	} else {
+		left, right = interleavedMultiExp(xs, xExps, m), interleavedMultiExp(zs, zExps, m)
+	}
+	return left != nil && right != nil && left.Cmp(right) == 0
+}
+
+// batchWindowWidth is the window width of interleavedMultiExp, whose table costs 2**batchWindowWidth-2
+// multiplications per term.
+const batchWindowWidth = 4
+
+// interleavedMultiExp computes the product of xs[i]**ys[i] mod m for an odd m > 1, like MultiExp.
+// Unlike MultiExp, which exponentiates each term on its own, it scans all the exponents together from their
+// highest window (Straus' method): the single accumulator is squared once per bit for all the terms and
+// multiplied by the power of xs[i] from a small table per term for each non-zero window of ys[i].
+// A negative exponent uses the inverse of its base, and the result is nil if that base is not invertible.
+func interleavedMultiExp(xs, ys []*big.Int, m *big.Int) *big.Int {
+	mWords := newNat(m)
+	k0, RR, numWords := montgomeryConstants(mWords)
+	var tables [][]nat
+	var yWords []nat
+	for i := range xs {
+		y := exponentOrZero(ys[i])
+		if y.Sign() == 0 {
+			continue
+		}
+		x := new(big.Int).Mod(xs[i], m)
+		if y.Sign() < 0 {
+			if x.ModInverse(x, m) == nil {
+				return nil
+			}
+			y = new(big.Int).Neg(y)
+		}
+		// table[d] is the Montgomery form of x**d, for the window values d > 0
+		_, power1 := montgomeryPowers(newNat(x), mWords, k0, RR, numWords)
+		table := make([]nat, 1<<batchWindowWidth)
+		table[1] = power1
+		for d := 2; d < len(table); d++ {
+			table[d] = nat(nil).montgomery(table[d-1], power1, mWords, k0, numWords)
+		}
+		tables = append(tables, table)
+		yWords = append(yWords, newNat(y))
+	}
+
+	z := power0Of(RR, mWords, k0, numWords)
+	temp := nat(nil).make(numWords)
+	numWindows := (maxBitLen(yWords) + batchWindowWidth - 1) / batchWindowWidth
+	for i := numWindows - 1; i >= 0; i-- {
+		if i < numWindows-1 {
+			for j := 0; j < batchWindowWidth; j++ {
+				temp = temp.montgomery(z, z, mWords, k0, numWords)
+				z, temp = temp, z
+			}
+		}
+		for k, y := range yWords {
+			if d := y.window(i*batchWindowWidth, batchWindowWidth); d != 0 {
+				temp = temp.montgomery(z, tables[k][d], mWords, k0, numWords)
+				z, temp = temp, z
+			}
+		}
+	}
+	zWords := assembleAndConvert(z, nil, mWords, k0, numWords).norm()
+	return new(big.Int).SetBits(zWords.intBits())
+}
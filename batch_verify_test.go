@@ -0,0 +1,72 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBatchVerifyExp(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	// the base with the negative exponent must be invertible mod the random n
+	inv := new(big.Int).Add(g, big1)
+	for new(big.Int).GCD(nil, nil, inv, n).Cmp(big1) != 0 {
+		inv.Add(inv, big1)
+	}
+	xs := []*big.Int{g, big.NewInt(3), inv, g}
+	ys := []*big.Int{xList[0], x4[1], big.NewInt(-5), nil}
+	zs := make([]*big.Int, len(xs))
+	for i := range xs {
+		zs[i] = new(big.Int).Exp(xs[i], exponentOrZero(ys[i]), n)
+	}
+	if !BatchVerifyExp(xs, ys, zs, n) {
+		t.Fatalf("BatchVerifyExp rejected valid triples")
+	}
+	if !BatchVerifyExp(nil, nil, nil, n) {
+		t.Errorf("BatchVerifyExp rejected an empty batch")
+	}
+
+	// a wrong result, unrelated to the right one, is caught with overwhelming probability
+	bad := append([]*big.Int(nil), zs...)
+	bad[1] = new(big.Int).Add(zs[1], big1)
+	if BatchVerifyExp(xs, ys, bad, n) {
+		t.Errorf("BatchVerifyExp accepted a wrong result")
+	}
+	// invalid inputs are rejected
+	if BatchVerifyExp(xs, ys, zs[:3], n) {
+		t.Errorf("BatchVerifyExp accepted slices of different lengths")
+	}
+	bad[1] = nil
+	if BatchVerifyExp(xs, ys, bad, n) {
+		t.Errorf("BatchVerifyExp accepted a nil result")
+	}
+
+	// a single random bit, i.e., soundness error 1/2, and a nil modulus
+	if !BatchVerifyExpBits(xs, ys, zs, n, 1) {
+		t.Errorf("BatchVerifyExpBits(k = 1) rejected valid triples")
+	}
+	small := []*big.Int{big.NewInt(81)}
+	if !BatchVerifyExpBits([]*big.Int{big.NewInt(3)}, []*big.Int{big.NewInt(4)}, small, nil, 0) {
+		t.Errorf("BatchVerifyExpBits rejected a valid triple without modulus")
+	}
+}
+
+func TestInterleavedMultiExp(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	// 2 is invertible mod any odd modulus, and the base above n is reduced first
+	xs := []*big.Int{g, big.NewInt(3), big.NewInt(0), big.NewInt(2), new(big.Int).Add(n, big.NewInt(5)), g}
+	ys := []*big.Int{xList[0], x4[1], big.NewInt(9), big.NewInt(-3), x4[2], nil}
+	for _, m := range []*big.Int{n, getPrime256(), big.NewInt(1000003)} {
+		if got, want := interleavedMultiExp(xs, ys, m), MultiExp(xs, ys, m); got.Cmp(want) != 0 {
+			t.Errorf("interleavedMultiExp mod %v = %v, want %v", m, got, want)
+		}
+	}
+	if got := interleavedMultiExp(nil, nil, n); got.Cmp(big1) != 0 {
+		t.Errorf("interleavedMultiExp of no term = %v, want 1", got)
+	}
+	// 3 has no inverse mod 9
+	if got := interleavedMultiExp([]*big.Int{big.NewInt(3)}, []*big.Int{big.NewInt(-1)}, big.NewInt(9)); got != nil {
+		t.Errorf("interleavedMultiExp inverted a non-invertible base: %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package multiexp
 
 import (
 	"crypto/rand"
+	"fmt"
 	"math/big"
 	"sync"
 	"testing"
@@ -197,3 +198,261 @@ func BenchmarkExpParallel16(b *testing.B) {
 		ExpParallel(g, xList[0], n, table, 16, 0)
 	}
 }
+
+func BenchmarkExpPrecomputed(b *testing.B) {
+	g, n, xList := getBenchParameters(1)
+	table := getBenchPrecomputeTable()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ExpPrecomputed(g, xList[0], n, table)
+	}
+}
+
+func BenchmarkExpPrecomputedWindowed(b *testing.B) {
+	g, n, xList := getBenchParameters(1)
+	table := getBenchPrecomputeTable()
+	for _, width := range []int{1, 2, 3, 4, 5} {
+		windowed := NewWindowedPreTable(table, width)
+		b.Run(fmt.Sprintf("width=%d", width), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ExpPrecomputedWindowed(g, xList[0], n, windowed)
+			}
+		})
+	}
+}
+
+// BenchmarkFourfoldChainsSplit measures the decomposition of four exponents into the shared chains,
+// to compare with the exponentiation itself in BenchmarkFourfoldExp.
+func BenchmarkFourfoldChainsSplit(b *testing.B) {
+	_, _, xList := getBenchParameters(4)
+	y := [4]nat{newNat(xList[0]), newNat(xList[1]), newNat(xList[2]), newNat(xList[3])}
+	b.Run("fourfoldChains", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fourfoldChains(y)
+		}
+	})
+	b.Run("gcw", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			gcw(y[0], y[1])
+		}
+	})
+}
+
+func BenchmarkFourfoldChainsWindowed(b *testing.B) {
+	g, n, xList := getBenchParameters(4)
+	x, m := newNat(g), newNat(n)
+	chains := fourfoldChains([4]nat{newNat(xList[0]), newNat(xList[1]), newNat(xList[2]), newNat(xList[3])})
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	b.Run("bit-by-bit", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			multiMontgomery(m, power0, power1, k0, numWords, chains[:])
+		}
+	})
+	for _, width := range []int{2, 3, 4, 5} {
+		b.Run(fmt.Sprintf("width=%d", width), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				multiMontgomeryWindowed(m, power0, power1, k0, numWords, chains[:], width, nil)
+			}
+		})
+	}
+}
+
+// BenchmarkDenseWindowed compares the shared loops on dense random exponents, the input of NfoldExp.
+func BenchmarkDenseWindowed(b *testing.B) {
+	g, n, xList := getBenchParameters(4)
+	x, m := newNat(g), newNat(n)
+	yList := make([]nat, len(xList))
+	for i := range yList {
+		yList[i] = newNat(xList[i])
+	}
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	b.Run("bit-by-bit", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			multiMontgomery(m, power0, power1, k0, numWords, yList)
+		}
+	})
+	for _, width := range []int{2, 3, 4, 5} {
+		b.Run(fmt.Sprintf("width=%d", width), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				multiMontgomeryWindowed(m, power0, power1, k0, numWords, yList, width, nil)
+			}
+		})
+	}
+}
+
+// multiMontgomeryLeftToRight calculates the same values as multiMontgomery, scanning the exponents from
+// their highest bits with fixed windows of width bits. The powers x**0 ... x**(2**width-1) are shared by
+// all the exponents, and each exponent only needs its running accumulator, squared width times per window.
+// Unlike the squarings of x in multiMontgomery, the squarings of the accumulators are not shared, so for
+// the 15 sparse chains of the fourfold path it is much slower, see BenchmarkFourfoldChainsLeftToRight.
+// It is kept here as the reference for that comparison.
+func multiMontgomeryLeftToRight(m, power0, power1 nat, k0 Word, numWords int, yList []nat, width int) []nat {
+	if width <= 0 || width >= _W {
+		width = 1
+	}
+	powers := make([]nat, 1<<uint(width))
+	powers[0] = power0
+	powers[1] = power1
+	for i := 2; i < len(powers); i++ {
+		powers[i] = nat(nil).montgomery(powers[i-1], power1, m, k0, numWords)
+	}
+
+	zList := make([]nat, len(yList))
+	temp := nat(nil).make(numWords)
+	for k, y := range yList {
+		z := nat(nil).make(numWords)
+		copy(z, power0)
+		// round the top bit up to a whole window, the first window has no squaring to do
+		topBit := (y.bitLen() + width - 1) / width * width
+		for i := topBit - width; i >= 0; i -= width {
+			if i+width < topBit {
+				for j := 0; j < width; j++ {
+					temp = temp.montgomery(z, z, m, k0, numWords)
+					z, temp = temp, z
+				}
+			}
+			if digit := y.window(i, width); digit != 0 {
+				temp = temp.montgomery(z, powers[digit], m, k0, numWords)
+				z, temp = temp, z
+			}
+		}
+		zList[k] = z
+	}
+	return zList
+}
+
+func BenchmarkFourfoldChainsLeftToRight(b *testing.B) {
+	g, n, xList := getBenchParameters(4)
+	x, m := newNat(g), newNat(n)
+	chains := fourfoldChains([4]nat{newNat(xList[0]), newNat(xList[1]), newNat(xList[2]), newNat(xList[3])})
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	b.Run("right-to-left", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			multiMontgomery(m, power0, power1, k0, numWords, chains[:])
+		}
+	})
+	for _, width := range []int{1, 4} {
+		b.Run(fmt.Sprintf("left-to-right/width=%d", width), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				multiMontgomeryLeftToRight(m, power0, power1, k0, numWords, chains[:], width)
+			}
+		})
+	}
+}
+
+func BenchmarkMontgomerySetup(b *testing.B) {
+	g, n, _ := getBenchParameters(1)
+	x, m := newNat(new(big.Int).Lsh(g, 3000)), newNat(n)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		montgomerySetup(x, m)
+	}
+}
+
+func BenchmarkExpWithMontContextLargeBase(b *testing.B) {
+	_, n, xList := getBenchParameters(1)
+	ctx := NewMontContext(n)
+	x := new(big.Int).Lsh(n, 1000)
+	x.Add(x, big.NewInt(3))
+	y := new(big.Int).Rsh(xList[0], 19000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ExpWithMontContext(x, y, ctx)
+	}
+}
+
+func BenchmarkExpTwoLevelPrecomputed(b *testing.B) {
+	g, n, xList := getBenchParameters(1)
+	for _, levelBits := range []int{1, 4, 16, 64} {
+		table := NewTwoLevelPreTable(g, n, numTestBits, levelBits)
+		b.Run(fmt.Sprintf("levelBits=%d", levelBits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ExpTwoLevelPrecomputed(g, xList[0], n, table)
+			}
+		})
+	}
+}
+
+func BenchmarkExpSeqProduct(b *testing.B) {
+	g, n, xList := getBenchParameters(4)
+	b.Run("shared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ExpSeqProduct(g, n, xList)
+		}
+	})
+	b.Run("sum", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sum := new(big.Int)
+			for _, y := range xList {
+				sum.Add(sum, y)
+			}
+			DefaultTables.Exp(g, sum, n)
+		}
+	})
+}
+
+func BenchmarkExpProduct(b *testing.B) {
+	g, n, _ := getBenchParameters(1)
+	primes := smallPrimes(1<<32, 1000)
+	b.Run("chain", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ExpProduct(g, primes, n)
+		}
+	})
+	b.Run("product", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			prod := big.NewInt(1)
+			for _, p := range primes {
+				prod.Mul(prod, p)
+			}
+			DefaultTables.Exp(g, prod, n)
+		}
+	})
+}
+
+func BenchmarkBatchVerifyExp(b *testing.B) {
+	_, n, xList := getBenchParameters(4)
+	xs := getDifferentBenchParameters(4)
+	zs := make([]*big.Int, len(xs))
+	for i := range xs {
+		zs[i] = new(big.Int).Exp(xs[i], xList[i], n)
+	}
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BatchVerifyExp(xs, xList, zs, n)
+		}
+	})
+	b.Run("naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range xs {
+				if new(big.Int).Exp(xs[j], xList[j], n).Cmp(zs[j]) != 0 {
+					b.Fatal("wrong result")
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkMultiMontgomerySparseTopWord(b *testing.B) {
+	g, n, xList := getBenchParameters(4)
+	x, m := newNat(g), newNat(n)
+	// exponents of a few words whose top word only has its lowest bit set
+	yList := make([]nat, len(xList))
+	for i := range yList {
+		y := new(big.Int).Lsh(big1, 4*_W)
+		y.Or(y, new(big.Int).Rsh(xList[i], uint(xList[i].BitLen()-4*_W)))
+		yList[i] = newNat(y)
+	}
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		multiMontgomery(m, power0, power1, k0, numWords, yList)
+	}
+}
@@ -0,0 +1,31 @@
+package multiexp
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// FourfoldExpChecked computes the results of FourfoldExp. When built with the multiexp_check tag,
+// each of the four results is also verified against big.Int.Exp, and an error naming the first
+// divergent slot is returned with the results, e.g., to canary a new version of the package against
+// the reference. Without the tag, it is FourfoldExp with a nil error and no extra cost.
+//
+// FourfoldExpChecked is not a cryptographically constant-time operation.
+func FourfoldExpChecked(x, m *big.Int, y4 [4]*big.Int) ([4]*big.Int, error) {
+	ret := FourfoldExp(x, m, y4)
+	if !checkResults {
+		return ret, nil
+	}
+	return ret, verifyFourfold(x, m, y4, ret)
+}
+
+// verifyFourfold compares the results of a fourfold exponentiation against big.Int.Exp.
+func verifyFourfold(x, m *big.Int, y4, results [4]*big.Int) error {
+	for i, y := range y4 {
+		want := new(big.Int).Exp(x, exponentOrZero(y), m)
+		if results[i] == nil || results[i].Cmp(want) != 0 {
+			return fmt.Errorf("multiexp: fourfold result %d is %v, big.Int.Exp gives %v", i, results[i], want)
+		}
+	}
+	return nil
+}
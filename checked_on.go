@@ -0,0 +1,8 @@
+//go:build multiexp_check
+// +build multiexp_check
+
+package multiexp
+
+// checkResults enables the verification of FourfoldExpChecked against big.Int,
+// and the internal consistency checks such as the one of k0 in montgomeryConstants.
+const checkResults = true
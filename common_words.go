@@ -2,34 +2,20 @@ package multiexp
 
 // gcw inputs two positive integer a and b, calculates the most common words
 // i.e. a = 11011111, b = 11100000, most common word(s) = 11000000
+// The inputs are not modified, the extra words of a and b are returned in new slices.
 func gcw(a, b nat) (nat, nat, nat) {
-	aExtra := nat(nil).make(len(a))
-	bExtra := nat(nil).make(len(b))
-	var minWordLen int
-	if len(a) > len(b) {
+	minWordLen := len(a)
+	if len(b) < minWordLen {
 		minWordLen = len(b)
-		for i := minWordLen; i < len(a); i++ {
-			aExtra[i] = a[i]
-		}
-	} else {
-		minWordLen = len(a)
-		for i := minWordLen; i < len(b); i++ {
-			bExtra[i] = b[i]
-		}
 	}
-
 	commonWords := nat(nil).make(minWordLen)
-	for i := 0; i < minWordLen; i++ {
-		commonWords[i] = a[i] & b[i]
-		aExtra[i] = a[i] - commonWords[i]
-		bExtra[i] = b[i] - commonWords[i]
-	}
-
-	return aExtra, bExtra, commonWords
+	andVV(commonWords, a, b)
+	return subCommon(a, commonWords), subCommon(b, commonWords), commonWords
 }
 
 // fourfoldGCW inputs four positive integer a, b, c, d and calculates the greatest common words
 // the last element in output is the common word slice
+// The inputs are not modified, the outputs are all new slices.
 func fourfoldGCW(input [4]nat) [5]nat {
 	maxWordLen := 0
 	minWordLen := len(input[0])
@@ -43,30 +29,20 @@ func fourfoldGCW(input [4]nat) [5]nat {
 	}
 
 	var outputs [5]nat
-	for i := 0; i < 4; i++ {
-		outputs[i] = outputs[i].make(len(input[i]))
-	}
 	outputs[4] = outputs[4].make(minWordLen)
-	for i := 0; i < minWordLen; i++ {
-		outputs[4][i] = input[0][i] & input[1][i] & input[2][i] & input[3][i]
-		outputs[0][i] = input[0][i] - outputs[4][i]
-		outputs[1][i] = input[1][i] - outputs[4][i]
-		outputs[2][i] = input[2][i] - outputs[4][i]
-		outputs[3][i] = input[3][i] - outputs[4][i]
-	}
+	andVV(outputs[4], input[0], input[1])
+	andVV(outputs[4], outputs[4], input[2])
+	andVV(outputs[4], outputs[4], input[3])
 	for i := 0; i < 4; i++ {
-		if len(outputs[i]) > minWordLen {
-			for j := minWordLen; j < len(outputs[i]); j++ {
-				outputs[i][j] = input[i][j]
-			}
-		}
+		outputs[i] = subCommon(input[i], outputs[4])
 	}
 
 	return outputs
 }
 
-// threefoldGcb inputs three positive integer a, b, c and calculates the greatest common words
-// the last element in output is the common word slice
+// threefoldGCW inputs three positive integer a, b, c and returns their greatest common words.
+// Unlike gcw and fourfoldGCW, the common words are subtracted from the inputs IN PLACE,
+// so the caller sees a, b and c without the returned bits afterwards.
 func threefoldGCW(input [3]nat) nat {
 	maxWordLen := 0
 	minWordLen := len(input[0])
@@ -80,11 +56,76 @@ func threefoldGCW(input [3]nat) nat {
 	}
 
 	output := nat(nil).make(minWordLen)
-	for i := 0; i < minWordLen; i++ {
-		output[i] = input[0][i] & input[1][i] & input[2][i]
-		input[0][i] = input[0][i] - output[i]
-		input[1][i] = input[1][i] - output[i]
-		input[2][i] = input[2][i] - output[i]
+	andVV(output, input[0], input[1])
+	andVV(output, output, input[2])
+	for i := 0; i < 3; i++ {
+		// the common words are subsets of the input words, so there is no borrow
+		subVV(input[i][:minWordLen], input[i], output)
 	}
 	return output
 }
+
+// subCommon returns a new slice of the length of x holding x minus common, where the words of
+// common are subsets of the corresponding words of x. There is no borrow between the words, so
+// the vector subtraction is the word-by-word one, and the words of x beyond common are copied.
+func subCommon(x, common nat) nat {
+	z := nat(nil).make(len(x))
+	subVV(z[:len(common)], x, common)
+	copy(z[len(common):], x[len(common):])
+	return z
+}
+
+// fourfoldChains splits four exponents into the 15 chains shared by the fourfold functions.
+// Every set bit of the inputs ends up in exactly one chain, selected by the set of inputs having that bit:
+//
+//	index:  0  1  2  3   4     5    6    7    8    9  10  11  12  13  14
+//	inputs: 0  1  2  3  0123  012  013  023  123  01  23  02  13  03  12
+//
+// The inputs are not modified. The threefoldGCW calls below subtract in place, but only
+// from the fresh outputs of fourfoldGCW, which are owned by this function. Each of them
+// can only extract bits not common to all four, and each gcw call only bits not common to
+// any three, so the order of the calls does not change the resulting partition.
+func fourfoldChains(y [4]nat) [15]nat {
+	// Zero round, find common bits of the four values
+	gcwList := fourfoldGCW(y)
+	// First round, find common bits of the three values
+	var cm012, cm013, cm023, cm123 nat
+	cm012 = threefoldGCW([3]nat{gcwList[0], gcwList[1], gcwList[2]})
+	cm013 = threefoldGCW([3]nat{gcwList[0], gcwList[1], gcwList[3]})
+	cm023 = threefoldGCW([3]nat{gcwList[0], gcwList[2], gcwList[3]})
+	cm123 = threefoldGCW([3]nat{gcwList[1], gcwList[2], gcwList[3]})
+	// Second round, find common bits of the two values
+	var cm01, cm23, cm02, cm13, cm03, cm12 nat
+	gcwList[0], gcwList[1], cm01 = gcw(gcwList[0], gcwList[1])
+	gcwList[2], gcwList[3], cm23 = gcw(gcwList[2], gcwList[3])
+	gcwList[0], gcwList[2], cm02 = gcw(gcwList[0], gcwList[2])
+	gcwList[1], gcwList[3], cm13 = gcw(gcwList[1], gcwList[3])
+	gcwList[0], gcwList[3], cm03 = gcw(gcwList[0], gcwList[3])
+	gcwList[1], gcwList[2], cm12 = gcw(gcwList[1], gcwList[2])
+
+	return [15]nat{gcwList[0], gcwList[1], gcwList[2], gcwList[3], gcwList[4],
+		cm012, cm013, cm023, cm123, cm01, cm23, cm02, cm13, cm03, cm12}
+}
+
+// fourfoldAssembly lists, for each output i of the fourfold functions, the chains of fourfoldChains
+// that are multiplied into chain i to get x**y[i]: the chains of every set of inputs containing i.
+//
+//	output 0: 0123, 012, 013, 023, 01, 02, 03
+//	output 1: 0123, 012, 013, 123, 01, 13, 12
+//	output 2: 0123, 012, 023, 123, 23, 02, 12
+//	output 3: 0123, 013, 023, 123, 23, 13, 03
+var fourfoldAssembly = [4][7]int{
+	{4, 5, 6, 7, 9, 11, 13},
+	{4, 5, 6, 8, 9, 12, 14},
+	{4, 5, 7, 8, 10, 11, 14},
+	{4, 6, 7, 8, 10, 12, 13},
+}
+
+// fourfoldAssemblySet returns the values of the shared chains in z needed by output i, following fourfoldAssembly.
+func fourfoldAssemblySet(z []nat, i int) []nat {
+	set := make([]nat, len(fourfoldAssembly[i]))
+	for j, c := range fourfoldAssembly[i] {
+		set[j] = z[c]
+	}
+	return set
+}
@@ -0,0 +1,167 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+// fourfoldChainSets lists the inputs sharing the bits of each chain returned by fourfoldChains.
+var fourfoldChainSets = [15][]int{
+	{0}, {1}, {2}, {3}, {0, 1, 2, 3},
+	{0, 1, 2}, {0, 1, 3}, {0, 2, 3}, {1, 2, 3},
+	{0, 1}, {2, 3}, {0, 2}, {1, 3}, {0, 3}, {1, 2},
+}
+
+// subsetExponents returns four exponents in which bit c of the word i (for the given
+// words) is set exactly in the inputs listed in fourfoldChainSets[c].
+func subsetExponents(words int) [4]nat {
+	var y [4]nat
+	for i := range y {
+		y[i] = make(nat, words)
+	}
+	for w := 0; w < words; w++ {
+		for c, set := range fourfoldChainSets {
+			for _, i := range set {
+				y[i][w] |= 1 << uint(c+w)
+			}
+		}
+	}
+	return y
+}
+
+func TestGCWDoesNotModifyInputs(t *testing.T) {
+	a, b := nat{0xdf, 0xf0}, nat{0xe0}
+	aExtra, bExtra, common := gcw(a, b)
+	if a.cmp(nat{0xdf, 0xf0}) != 0 || b.cmp(nat{0xe0}) != 0 {
+		t.Errorf("gcw modified its inputs: %v, %v", a, b)
+	}
+	if aExtra.cmp(nat{0x1f, 0xf0}) != 0 || bExtra.norm().cmp(nat{0x20}) != 0 || common.cmp(nat{0xc0}) != 0 {
+		t.Errorf("gcw(a, b) = %v, %v, %v", aExtra, bExtra, common)
+	}
+}
+
+func TestThreefoldGCWModifiesInputs(t *testing.T) {
+	input := [3]nat{{0x7, 0x1}, {0x6}, {0xe, 0x3}}
+	common := threefoldGCW(input)
+	if common.cmp(nat{0x6}) != 0 {
+		t.Errorf("threefoldGCW common = %v, want [6]", common)
+	}
+	// the common words are subtracted in place, the words beyond the shortest input are kept
+	want := [3]nat{{0x1, 0x1}, {0x0}, {0x8, 0x3}}
+	for i := range input {
+		if input[i].cmp(want[i]) != 0 {
+			t.Errorf("threefoldGCW input[%d] = %v, want %v", i, input[i], want[i])
+		}
+	}
+}
+
+func TestFourfoldChains(t *testing.T) {
+	for _, words := range []int{1, 3} {
+		y := subsetExponents(words)
+		var saved [4]nat
+		for i := range y {
+			saved[i] = nat(nil).set(y[i])
+		}
+
+		// after the zero round, only the bits of 0123 are common
+		gcwList := fourfoldGCW(y)
+		for w := 0; w < words; w++ {
+			if gcwList[4][w] != 1<<uint(4+w) {
+				t.Errorf("fourfoldGCW common word %d = %x", w, gcwList[4][w])
+			}
+		}
+
+		chains := fourfoldChains(y)
+		for i := range y {
+			if y[i].cmp(saved[i]) != 0 {
+				t.Errorf("fourfoldChains modified input %d", i)
+			}
+		}
+		// every chain must hold exactly the bit of its set in every word
+		for c := range chains {
+			for w := 0; w < words; w++ {
+				var got Word
+				if w < len(chains[c]) {
+					got = chains[c][w]
+				}
+				if got != 1<<uint(c+w) {
+					t.Errorf("words = %d, chain %d %v word %d = %x, want %x",
+						words, c, fourfoldChainSets[c], w, got, Word(1)<<uint(c+w))
+				}
+			}
+		}
+	}
+}
+
+func TestFourfoldChainsPartition(t *testing.T) {
+	y := [4]nat{{0xdeadbeef, 0x1234}, {0xfeedface}, {0xcafebabe, 0x5678, 0x9}, {0x8badf00d, 0x4321}}
+	chains := fourfoldChains(y)
+	for w := 0; w < 3; w++ {
+		for b := 0; b < _W; b++ {
+			// collect the inputs having bit b of word w
+			var set []int
+			for i := range y {
+				if w < len(y[i]) && y[i][w]&(1<<uint(b)) != 0 {
+					set = append(set, i)
+				}
+			}
+			found := 0
+			for c := range chains {
+				if w < len(chains[c]) && chains[c][w]&(1<<uint(b)) != 0 {
+					found++
+					if !sameSet(set, fourfoldChainSets[c]) {
+						t.Errorf("bit %d of word %d is in chain %d, but set in %v", b, w, c, set)
+					}
+				}
+			}
+			if (len(set) > 0 && found != 1) || (len(set) == 0 && found != 0) {
+				t.Errorf("bit %d of word %d is in %d chains, set in %v", b, w, found, set)
+			}
+		}
+	}
+}
+
+func sameSet(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFourfoldAssembly(t *testing.T) {
+	// derive the mapping independently: output i needs every other chain whose set contains i
+	for i := range fourfoldAssembly {
+		var want []int
+		for c, set := range fourfoldChainSets {
+			if c == i {
+				continue
+			}
+			for _, j := range set {
+				if j == i {
+					want = append(want, c)
+				}
+			}
+		}
+		if !sameSet(want, fourfoldAssembly[i][:]) {
+			t.Errorf("fourfoldAssembly[%d] = %v, want %v", i, fourfoldAssembly[i], want)
+		}
+	}
+
+	// the chains of each output must add up to its exponent again
+	y := [4]nat{{0xdeadbeef, 0x1234}, {0xfeedface}, {0xcafebabe, 0x5678, 0x9}, {0x8badf00d, 0x4321}}
+	chains := fourfoldChains(y)
+	for i := range y {
+		sum := new(big.Int).SetBits(chains[i].norm().intBits())
+		for _, c := range fourfoldAssembly[i] {
+			sum.Add(sum, new(big.Int).SetBits(chains[c].norm().intBits()))
+		}
+		if want := new(big.Int).SetBits(y[i].intBits()); sum.Cmp(want) != 0 {
+			t.Errorf("chains of output %d add up to %x, want %x", i, sum, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package multiexp
+
+import (
+	"sync"
+)
+
+// divScratch holds the dividend, quotient and remainder buffers of a division, kept across calls
+// in divScratchPool so that the reductions of the Montgomery setup and of the final conversions
+// do not reallocate them. The results must be copied out before the scratch is put back.
+type divScratch struct {
+	u, q, r nat
+}
+
+// divScratchPool pools the *divScratch buffers of the package.
+var divScratchPool sync.Pool
+
+// getDivScratch returns a *divScratch from divScratchPool, to be put back with putDivScratch.
+func getDivScratch() *divScratch {
+	s, _ := divScratchPool.Get().(*divScratch)
+	if s == nil {
+		s = new(divScratch)
+	}
+	return s
+}
+
+func putDivScratch(s *divScratch) {
+	divScratchPool.Put(s)
+}
@@ -0,0 +1,23 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDivScratchAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items at random under the race detector")
+	}
+	g, n, _ := getBenchParameters(1)
+	m := newNat(n)
+	long := newNat(new(big.Int).Lsh(g, 3000))
+	// the divisions run in the pooled buffers, only the results are allocated:
+	// RR, then the padded base, one, power0, power1 and RR
+	if got := testing.AllocsPerRun(100, func() { montgomeryConstants(m) }); got > 1 {
+		t.Errorf("montgomeryConstants: %v allocations, want 1", got)
+	}
+	if got := testing.AllocsPerRun(100, func() { montgomerySetup(long, m) }); got > 5 {
+		t.Errorf("montgomerySetup with a long base: %v allocations, want 5", got)
+	}
+}
@@ -0,0 +1,98 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// DoubleExpState is a DoubleExp computation that can be resumed when the exponents grow.
+// It keeps the squaring chain of x and the partial products over the exponent bits processed so far,
+// so extending the exponents by higher bits only costs the squarings and multiplications of the new bits.
+// A DoubleExpState is not safe for concurrent use.
+type DoubleExpState struct {
+	Base     *big.Int
+	Modulus  *big.Int
+	m        nat
+	k0       Word
+	numWords int
+	// bits is the number of low exponent bits processed
+	bits int
+	// squaredPower is x**(2**bits) in Montgomery form
+	squaredPower nat
+	// z holds the Montgomery forms of x to the bits of y1 only, of y2 only and of both, like gcw
+	z [3]nat
+	// y holds the low bits of the exponents processed so far
+	y [2]*big.Int
+}
+
+// NewDoubleExpState creates a resumable DoubleExp computation of the powers of x mod m,
+// starting from exponents 0. It returns nil if x <= 1 or if m is nil, non-positive or even.
+func NewDoubleExpState(x, m *big.Int) *DoubleExpState {
+	if x == nil || x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return nil
+	}
+	xWords, mWords := newNat(x), newNat(m)
+	power0, power1, k0, numWords := montgomerySetup(xWords, mWords)
+	s := &DoubleExpState{
+		Base:         new(big.Int).Set(x),
+		Modulus:      new(big.Int).Set(m),
+		m:            mWords,
+		k0:           k0,
+		numWords:     numWords,
+		squaredPower: power1,
+		y:            [2]*big.Int{new(big.Int), new(big.Int)},
+	}
+	for i := range s.z {
+		s.z[i] = nat(nil).set(power0)
+	}
+	return s
+}
+
+// Extend returns x**y1 mod m and x**y2 mod m, resuming the computation from the bits processed by the
+// previous calls. The exponents must be non-negative and agree with the exponents of the previous calls
+// on the bits processed so far, i.e., only grow by higher bits; otherwise Extend panics.
+//
+// Extend is not a cryptographically constant-time operation.
+func (s *DoubleExpState) Extend(y2 [2]*big.Int) [2]*big.Int {
+	mask := new(big.Int).Lsh(big1, uint(s.bits))
+	mask.Sub(mask, big1)
+	for i := range y2 {
+		if y2[i] == nil || y2[i].Sign() < 0 {
+			panic("invalid y2: nil or negative value")
+		}
+		if new(big.Int).And(y2[i], mask).Cmp(s.y[i]) != 0 {
+			panic("invalid y2: the processed low bits differ")
+		}
+	}
+
+	top := y2[0].BitLen()
+	if l := y2[1].BitLen(); l > top {
+		top = l
+	}
+	temp := nat(nil).make(s.numWords)
+	for b := s.bits; b < top; b++ {
+		b0, b1 := y2[0].Bit(b) == 1, y2[1].Bit(b) == 1
+		// the common bits are multiplied only once, like gcw
+		for k, set := range [3]bool{b0 && !b1, b1 && !b0, b0 && b1} {
+			if set {
+				temp = temp.montgomery(s.z[k], s.squaredPower, s.m, s.k0, s.numWords)
+				s.z[k], temp = temp, s.z[k]
+			}
+		}
+		temp = temp.montgomery(s.squaredPower, s.squaredPower, s.m, s.k0, s.numWords)
+		s.squaredPower, temp = temp, s.squaredPower
+	}
+	if top > s.bits {
+		s.bits = top
+		for i := range y2 {
+			s.y[i].Set(y2[i])
+		}
+	}
+
+	var ret [2]*big.Int
+	for i := range ret {
+		// assembleAndConvert overwrites its first argument, the state keeps its own
+		zWords := assembleAndConvert(nat(nil).set(s.z[i]), []nat{s.z[2]}, s.m, s.k0, s.numWords).norm()
+		ret[i] = new(big.Int).SetBits(zWords.intBits())
+	}
+	return ret
+}
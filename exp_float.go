@@ -0,0 +1,33 @@
+package multiexp
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	// ErrNegativeExponent is returned by ExpFloat for a negative exponent.
+	ErrNegativeExponent = errors.New("multiexp: negative exponent")
+	// ErrInfiniteExponent is returned by ExpFloat for an infinite exponent.
+	ErrInfiniteExponent = errors.New("multiexp: infinite exponent")
+)
+
+// ExpFloat computes x**y mod |m| for the exponent y truncated toward zero to an integer,
+// using the tables of DefaultTables when there is one registered for x and m.
+// It returns ErrNegativeExponent if y < 0, even if it truncates to 0, and ErrInfiniteExponent if y is ±Inf.
+// A big.Float never holds NaN; a nil y returns an error too.
+// ExpFloat is not a cryptographically constant-time operation.
+func ExpFloat(x *big.Int, y *big.Float, m *big.Int) (*big.Int, error) {
+	if y == nil {
+		return nil, errors.New("multiexp: nil exponent")
+	}
+	if y.IsInf() {
+		return nil, ErrInfiniteExponent
+	}
+	if y.Sign() < 0 {
+		return nil, ErrNegativeExponent
+	}
+	// the accuracy only tells whether the fractional part was dropped
+	yInt, _ := y.Int(nil)
+	return DefaultTables.Exp(x, yInt, m), nil
+}
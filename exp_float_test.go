@@ -0,0 +1,45 @@
+package multiexp
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestExpFloat(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	for _, y := range []*big.Float{
+		new(big.Float).SetInt(xList[0]),
+		big.NewFloat(12345.99),
+		big.NewFloat(0.75),
+		big.NewFloat(0),
+	} {
+		want, _ := y.Int(nil)
+		want.Exp(g, want, n)
+		got, err := ExpFloat(g, y, n)
+		if err != nil {
+			t.Fatalf("ExpFloat(%v) returned error %v", y, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpFloat(%v)", y)
+		}
+	}
+
+	for _, tc := range []struct {
+		y   *big.Float
+		err error
+	}{
+		{big.NewFloat(-3), ErrNegativeExponent},
+		{big.NewFloat(-0.5), ErrNegativeExponent},
+		{big.NewFloat(math.Inf(1)), ErrInfiniteExponent},
+		{big.NewFloat(math.Inf(-1)), ErrInfiniteExponent},
+	} {
+		if _, err := ExpFloat(g, tc.y, n); !errors.Is(err, tc.err) {
+			t.Errorf("ExpFloat(%v) returned error %v, want %v", tc.y, err, tc.err)
+		}
+	}
+	if _, err := ExpFloat(g, nil, n); err == nil {
+		t.Errorf("ExpFloat accepted a nil exponent")
+	}
+}
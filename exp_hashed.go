@@ -0,0 +1,46 @@
+package multiexp
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/big"
+)
+
+// hashedExtraBits is the number of bits hashed beyond the bit length of the order,
+// which makes the bias of the reduction mod order at most 2**-hashedExtraBits.
+const hashedExtraBits = 128
+
+// ExpHashed computes x**e mod |m| for the exponent e derived from the transcript, e.g., a Fiat–Shamir challenge.
+// The transcript is hashed with h into an integer of hashedExtraBits more bits than order,
+// which is reduced mod order, so that e is uniform in [0, order) up to a negligible bias.
+// ExpHashed panics if order is nil or not positive, or if h is nil.
+//
+// ExpHashed is not a cryptographically constant-time operation.
+func ExpHashed(x *big.Int, transcript []byte, order, m *big.Int, h func() hash.Hash) *big.Int {
+	if order == nil || order.Sign() <= 0 {
+		panic("invalid order: non-positive value")
+	}
+	if h == nil {
+		panic("hash constructor is nil")
+	}
+	e := hashToInt(transcript, order, h)
+	return DefaultTables.Exp(x, e, m)
+}
+
+// hashToInt hashes the transcript into an integer uniform in [0, order) up to a negligible bias.
+// The hash output is extended by hashing a 4-byte big-endian counter followed by the transcript
+// until enough bytes are produced.
+func hashToInt(transcript []byte, order *big.Int, h func() hash.Hash) *big.Int {
+	numBytes := (order.BitLen() + hashedExtraBits + 7) / 8
+	digest := make([]byte, 0, numBytes)
+	var counter [4]byte
+	for i := uint32(0); len(digest) < numBytes; i++ {
+		hh := h()
+		binary.BigEndian.PutUint32(counter[:], i)
+		hh.Write(counter[:])
+		hh.Write(transcript)
+		digest = hh.Sum(digest)
+	}
+	e := new(big.Int).SetBytes(digest[:numBytes])
+	return e.Mod(e, order)
+}
@@ -0,0 +1,34 @@
+package multiexp
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"math/big"
+	"testing"
+)
+
+func TestExpHashed(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	order := getPrime256()
+	// the order needs more bytes than a single SHA-256 digest
+	longOrder := new(big.Int).Lsh(order, 1000)
+	for _, h := range []func() hash.Hash{sha256.New, sha512.New} {
+		for _, q := range []*big.Int{order, longOrder, big.NewInt(7)} {
+			e := hashToInt([]byte("transcript"), q, h)
+			if e.Sign() < 0 || e.Cmp(q) >= 0 {
+				t.Fatalf("hashed exponent %v is not in [0, %v)", e, q)
+			}
+			if e.Cmp(hashToInt([]byte("transcript"), q, h)) != 0 {
+				t.Errorf("hashToInt is not deterministic")
+			}
+			if q.BitLen() > 64 && e.Cmp(hashToInt([]byte("transcripu"), q, h)) == 0 {
+				t.Errorf("different transcripts hashed to the same exponent")
+			}
+			got := ExpHashed(g, []byte("transcript"), q, n, h)
+			if want := new(big.Int).Exp(g, e, n); got.Cmp(want) != 0 {
+				t.Errorf("Wrong result for ExpHashed")
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// ExpModPower computes x**y mod |m|**e, e.g., x**y mod m**2 for Hensel lifting. The power of m is formed
+// with nat multiplications, and since it is odd for an odd m, the usual Montgomery exponentiation applies
+// with the number of words of m**e, about e times that of m.
+// The inputs that do not take the fast path of Exp, e.g., an even m, are passed to the default Exp function
+// with the modulus m**e. ExpModPower panics if e < 1.
+//
+// ExpModPower is not a cryptographically constant-time operation.
+func ExpModPower(x, y, m *big.Int, e int) *big.Int {
+	if e < 1 {
+		panic("invalid e: less than 1")
+	}
+	y = exponentOrZero(y)
+	var me *big.Int
+	if m != nil {
+		me = new(big.Int).SetBits(natPow(newNat(new(big.Int).Abs(m)), e).intBits())
+	}
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return new(big.Int).Exp(x, y, me)
+	}
+	zWords := expNNMontgomery(newNat(x), newNat(y), newNat(me))
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// natPow returns x**e for e >= 0 by square-and-multiply.
+func natPow(x nat, e int) nat {
+	z := nat(nil).setWord(1)
+	for p := x; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			z = nat(nil).mul(z, p)
+		}
+		if e > 1 {
+			p = nat(nil).mul(p, p)
+		}
+	}
+	return z
+}
@@ -0,0 +1,43 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestExpModPower(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	for _, tc := range []struct {
+		m *big.Int
+		e int
+	}{
+		{n, 1},
+		{n, 2},
+		{n, 3},
+		{big.NewInt(1000), 2},
+		{big.NewInt(-15), 2},
+	} {
+		me := new(big.Int).Exp(tc.m, big.NewInt(int64(tc.e)), nil)
+		if got, want := ExpModPower(g, xList[0], tc.m, tc.e), new(big.Int).Exp(g, xList[0], me); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpModPower mod %v**%d", tc.m, tc.e)
+		}
+	}
+	// the result is only reduced by m**e
+	n2 := new(big.Int).Mul(n, n)
+	x := new(big.Int).Add(n2, big.NewInt(3))
+	if got, want := ExpModPower(x, big.NewInt(5), n, 2), new(big.Int).Exp(big.NewInt(3), big.NewInt(5), n2); got.Cmp(want) != 0 {
+		t.Errorf("ExpModPower(m**2 + 3, 5, m, 2) = %v, want %v", got, want)
+	}
+	for _, e := range []int{0, 1, 2, 5, 8} {
+		if got, want := natPow(nat{3}, e), new(big.Int).Exp(big.NewInt(3), big.NewInt(int64(e)), nil); new(big.Int).SetBits(got.intBits()).Cmp(want) != 0 {
+			t.Errorf("natPow(3, %d) = %v, want %v", e, got, want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("ExpModPower did not panic for e = 0")
+		}
+	}()
+	ExpModPower(g, xList[0], n, 0)
+}
@@ -0,0 +1,29 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// ExpPrime computes x**y mod p for a prime p, reducing y modulo p-1 first (Fermat's little theorem),
+// which shrinks exponents much larger than p, e.g., products of many primes. The exponentiation uses
+// the tables of DefaultTables when there is one registered for x and p.
+// If x ≡ 0 mod p, the result is 0 for y > 0 and 1 for y == 0; otherwise, a y reducing to 0 gives 1.
+// A negative y gives the inverse of x**|y|, or nil if x ≡ 0 mod p. A nil y is treated as 0.
+// p is not checked for primality, and p <= 1 is passed to the default Exp function.
+//
+// ExpPrime is not a cryptographically constant-time operation.
+func ExpPrime(x, y, p *big.Int) *big.Int {
+	y = exponentOrZero(y)
+	if p == nil || p.Cmp(big1) <= 0 || y.Sign() == 0 {
+		return new(big.Int).Exp(x, y, p)
+	}
+	if new(big.Int).Mod(x, p).Sign() == 0 {
+		if y.Sign() < 0 {
+			return nil
+		}
+		return new(big.Int)
+	}
+	// Mod gives 0 <= e < p-1 for a negative y too, i.e., the exponent of the inverse
+	e := new(big.Int).Mod(y, new(big.Int).Sub(p, big1))
+	return DefaultTables.Exp(x, e, p)
+}
@@ -0,0 +1,39 @@
+package multiexp
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestExpPrime(t *testing.T) {
+	p, err := rand.Prime(rand.Reader, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, _, xList := getBenchParameters(1)
+	pm1 := new(big.Int).Sub(p, big1)
+	for _, tc := range []struct {
+		name string
+		x, y *big.Int
+		want *big.Int
+	}{
+		{"large exponent", g, xList[0], new(big.Int).Exp(g, xList[0], p)},
+		{"multiple of p-1", g, new(big.Int).Mul(pm1, big.NewInt(12345)), big.NewInt(1)},
+		{"zero exponent", p, big.NewInt(0), big.NewInt(1)},
+		{"nil exponent", g, nil, big.NewInt(1)},
+		{"x ≡ 0", new(big.Int).Mul(p, big.NewInt(7)), pm1, big.NewInt(0)},
+		{"negative exponent", g, big.NewInt(-5), new(big.Int).Exp(g, big.NewInt(-5), p)},
+		{"negative base", big.NewInt(-3), xList[0], new(big.Int).Exp(big.NewInt(-3), xList[0], p)},
+	} {
+		if got := ExpPrime(tc.x, tc.y, p); got == nil || got.Cmp(tc.want) != 0 {
+			t.Errorf("%s: ExpPrime = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got := ExpPrime(big.NewInt(3), xList[0], big.NewInt(2)); got.Cmp(big1) != 0 {
+		t.Errorf("ExpPrime(3, y, 2) = %v, want 1", got)
+	}
+	if got := ExpPrime(p, big.NewInt(-1), p); got != nil {
+		t.Errorf("ExpPrime(p, -1, p) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,69 @@
+package multiexp
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// radixBits is the number of bits per digit of the exponents of ExpRadix.
+const radixBits = 32
+
+// ExpRadix computes x**y mod |m| for the exponent y given by its little-endian digits in radix 2**32,
+// whatever the word size of the platform is, without converting them to a big.Int first.
+// An empty digit slice is the exponent 0.
+//
+// ExpRadix is not a cryptographically constant-time operation.
+func ExpRadix(x *big.Int, yDigits []uint32, m *big.Int) *big.Int {
+	// the exponent ends at its highest non-zero digit
+	top := len(yDigits)
+	for top > 0 && yDigits[top-1] == 0 {
+		top--
+	}
+	yDigits = yDigits[:top]
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || len(yDigits) == 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return new(big.Int).Exp(x, radixToInt(yDigits), m)
+	}
+	zWords := expNNMontgomeryRadix(newNat(x), yDigits, newNat(m))
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// expNNMontgomeryRadix calculates x**y mod m for the normalized digits of y in radix 2**32,
+// scanning each digit from its lowest bit and stopping the squarings at the highest set bit.
+func expNNMontgomeryRadix(x nat, yDigits []uint32, m nat) nat {
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	z := nat(nil).make(numWords)
+	copy(z, power0)
+	squaredPower := power1
+	temp := nat(nil).make(numWords)
+	for i, d := range yDigits {
+		// the last digit only has its bit length of bits to scan
+		n := radixBits
+		if i == len(yDigits)-1 {
+			n = bits.Len32(d)
+		}
+		for j := 0; j < n; j++ {
+			if d&(1<<uint(j)) != 0 {
+				temp = temp.montgomery(z, squaredPower, m, k0, numWords)
+				z, temp = temp, z
+			}
+			if i == len(yDigits)-1 && j == n-1 {
+				break
+			}
+			temp = temp.montgomery(squaredPower, squaredPower, m, k0, numWords)
+			squaredPower, temp = temp, squaredPower
+		}
+	}
+	return assembleAndConvert(z, nil, m, k0, numWords).norm()
+}
+
+// radixToInt converts the little-endian digits in radix 2**32 to a big.Int.
+func radixToInt(yDigits []uint32) *big.Int {
+	y := new(big.Int)
+	for i := len(yDigits) - 1; i >= 0; i-- {
+		y.Lsh(y, radixBits)
+		y.Or(y, new(big.Int).SetUint64(uint64(yDigits[i])))
+	}
+	return y
+}
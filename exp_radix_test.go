@@ -0,0 +1,35 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestExpRadix(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	// the digits of xList[0] in radix 2**32
+	var digits []uint32
+	for y := new(big.Int).Set(xList[0]); y.Sign() > 0; y.Rsh(y, radixBits) {
+		digits = append(digits, uint32(y.Uint64()))
+	}
+	for _, yDigits := range [][]uint32{
+		digits,
+		{1},
+		{0, 1},
+		{0xffffffff, 0, 0x80000000},
+		// leading zero digits
+		{5, 0, 0},
+		{},
+		{0, 0},
+	} {
+		y := radixToInt(yDigits)
+		for _, m := range []*big.Int{n, big.NewInt(1000)} {
+			if got, want := ExpRadix(g, yDigits, m), new(big.Int).Exp(g, y, m); got.Cmp(want) != 0 {
+				t.Errorf("Wrong result for ExpRadix with %d digits mod %v", len(yDigits), m)
+			}
+		}
+	}
+	if y := radixToInt(digits); y.Cmp(xList[0]) != 0 {
+		t.Errorf("radixToInt did not reconstruct the exponent")
+	}
+}
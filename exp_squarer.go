@@ -0,0 +1,70 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// squarerWindowWidth is the width of the fixed windows scanned by ExpWithSquarer.
+const squarerWindowWidth = 4
+
+// ExpWithSquarer computes x**y mod |m| with the modular squaring and multiplication supplied by the caller,
+// e.g., offloaded to hardware, while the package drives the scan of y: left to right, in fixed windows of
+// 4 bits, so the cost is one square per bit of y, one multiplication per non-zero window, and 14
+// multiplications for the powers x**2 ... x**15.
+// square(a) and mul(a, b) must return a**2 mod |m| and a*b mod |m| for reduced a and b, without modifying
+// them; their results are only passed back to them, except the last one, which is returned.
+// With nil square and mul, the internal Montgomery exponentiation is used; a nil square alone is mul(a, a).
+// The inputs that do not take the fast path of Exp are passed to the default Exp function, without calling
+// square and mul. ExpWithSquarer panics if only mul is nil.
+//
+// ExpWithSquarer is not a cryptographically constant-time operation.
+func ExpWithSquarer(x, y, m *big.Int, square func(a *big.Int) *big.Int, mul func(a, b *big.Int) *big.Int) *big.Int {
+	if mul == nil && square != nil {
+		panic("multiexp: nil mul with a non-nil square")
+	}
+	y = exponentOrZero(y)
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return new(big.Int).Exp(x, y, m)
+	}
+	if mul == nil {
+		zWords := expNNMontgomery(newNat(x), newNat(y), newNat(m))
+		return new(big.Int).SetBits(zWords.intBits())
+	}
+	if square == nil {
+		square = func(a *big.Int) *big.Int {
+			return mul(a, a)
+		}
+	}
+
+	// powers[i] = x**i mod |m|, powers[0] is never used
+	powers := make([]*big.Int, 1<<squarerWindowWidth)
+	powers[1] = new(big.Int).Mod(x, m)
+	for i := 2; i < len(powers); i++ {
+		powers[i] = mul(powers[i-1], powers[1])
+	}
+
+	// round the top bit up to a whole window, z stays nil until the first non-zero window
+	var z *big.Int
+	topBit := (y.BitLen() + squarerWindowWidth - 1) / squarerWindowWidth * squarerWindowWidth
+	for i := topBit - squarerWindowWidth; i >= 0; i -= squarerWindowWidth {
+		if z != nil {
+			for j := 0; j < squarerWindowWidth; j++ {
+				z = square(z)
+			}
+		}
+		var digit uint
+		for j := squarerWindowWidth - 1; j >= 0; j-- {
+			digit = digit<<1 | y.Bit(i+j)
+		}
+		switch {
+		case digit == 0:
+		case z == nil:
+			z = powers[digit]
+		default:
+			z = mul(z, powers[digit])
+		}
+	}
+	return z
+}
@@ -0,0 +1,56 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestExpWithSquarer(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	var squares, muls int
+	square := func(a *big.Int) *big.Int {
+		squares++
+		z := new(big.Int).Mul(a, a)
+		return z.Mod(z, n)
+	}
+	mul := func(a, b *big.Int) *big.Int {
+		muls++
+		z := new(big.Int).Mul(a, b)
+		return z.Mod(z, n)
+	}
+	for _, y := range []*big.Int{xList[0], big.NewInt(1), big.NewInt(16), big.NewInt(0x1234567)} {
+		want := new(big.Int).Exp(g, y, n)
+		squares, muls = 0, 0
+		if got := ExpWithSquarer(g, y, n, square, mul); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpWithSquarer(%d bits)", y.BitLen())
+		}
+		// the square count only depends on the windows below the top one
+		if wantSquares := (y.BitLen() - 1) / squarerWindowWidth * squarerWindowWidth; squares != wantSquares {
+			t.Errorf("ExpWithSquarer(%d bits) called square %d times, want %d", y.BitLen(), squares, wantSquares)
+		}
+		if got := ExpWithSquarer(g, y, n, nil, mul); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpWithSquarer(%d bits) with a nil square", y.BitLen())
+		}
+		if got := ExpWithSquarer(g, y, n, nil, nil); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpWithSquarer(%d bits) with the default functions", y.BitLen())
+		}
+	}
+
+	// the default path never calls the backend
+	squares, muls = 0, 0
+	for _, tc := range [][3]*big.Int{{g, big.NewInt(0), n}, {big1, xList[0], n}, {g, xList[0], big.NewInt(1000)}} {
+		if got, want := ExpWithSquarer(tc[0], tc[1], tc[2], square, mul), new(big.Int).Exp(tc[0], tc[1], tc[2]); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpWithSquarer on the default path")
+		}
+	}
+	if squares != 0 || muls != 0 {
+		t.Errorf("the default path called square %d and mul %d times", squares, muls)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("ExpWithSquarer did not panic for a nil mul")
+		}
+	}()
+	ExpWithSquarer(g, xList[0], n, square, nil)
+}
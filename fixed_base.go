@@ -0,0 +1,85 @@
+package multiexp
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+)
+
+// FixedBaseExp computes powers of a fixed base with a pre-computation table built on first use and extended
+// when an exponent longer than the table shows up, so the caller does not size the table in advance.
+// The table grows to at least twice its previous size, so a slowly growing exponent length only rebuilds
+// it a logarithmic number of times. FixedBaseExp is safe for concurrent use: the calls read the current
+// table without locking, and a larger table replaces it once complete, sharing the rows of the old one.
+type FixedBaseExp struct {
+	Base    *big.Int
+	Modulus *big.Int
+	x, m    nat
+	// one is set for a base congruent to 1, which has no table
+	one bool
+	// mu serializes the growth of table
+	mu    sync.Mutex
+	table atomic.Pointer[PreTable]
+}
+
+// NewFixedBaseExp creates a FixedBaseExp for the powers of g mod m, without any table yet.
+// It returns nil if g <= 1 or if m is nil, non-positive or even.
+func NewFixedBaseExp(g, m *big.Int) *FixedBaseExp {
+	if g == nil || g.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return nil
+	}
+	return &FixedBaseExp{
+		Base:    new(big.Int).Set(g),
+		Modulus: new(big.Int).Set(m),
+		x:       newNat(g),
+		m:       newNat(m),
+		one:     new(big.Int).Mod(g, m).Cmp(big1) == 0,
+	}
+}
+
+// TableSize returns the number of rows of the current table, 0 before the first use.
+func (f *FixedBaseExp) TableSize() int {
+	if t := f.table.Load(); t != nil {
+		return t.TableSize
+	}
+	return 0
+}
+
+// Exp computes g**y mod m, building or extending the table first if y is longer than it.
+// A nil y is treated as 0, and a negative y is passed to the default Exp function.
+//
+// Exp is not a cryptographically constant-time operation.
+func (f *FixedBaseExp) Exp(y *big.Int) *big.Int {
+	y = exponentOrZero(y)
+	if y.Sign() <= 0 || f.one {
+		return new(big.Int).Exp(f.Base, y, f.Modulus)
+	}
+	yWords := newNat(y)
+	t := f.table.Load()
+	if t == nil || t.TableSize < len(yWords) {
+		t = f.grow(len(yWords))
+	}
+	zWords := expNNMontgomeryPrecomputed(f.x, yWords, f.m, t)
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// grow returns a table of at least tableSize rows, building or extending the current one.
+func (f *FixedBaseExp) grow(tableSize int) *PreTable {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// another call may have grown the table meanwhile
+	t := f.table.Load()
+	if t != nil && t.TableSize >= tableSize {
+		return t
+	}
+	if t == nil {
+		t = NewPrecomputeTable(f.Base, f.Modulus, tableSize)
+	} else {
+		if 2*t.TableSize > tableSize {
+			tableSize = 2 * t.TableSize
+		}
+		t = t.extend(tableSize)
+	}
+	f.table.Store(t)
+	return t
+}
@@ -0,0 +1,66 @@
+package multiexp
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func TestFixedBaseExp(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	f := NewFixedBaseExp(g, n)
+	if f.TableSize() != 0 {
+		t.Fatalf("a new FixedBaseExp has a table of %d rows", f.TableSize())
+	}
+	// growing exponents: built for the first one, then extended, then reused
+	for _, bits := range []int{100, 64 * 20, 64*20 + 1, 64 * 50, 64 * 10} {
+		y := new(big.Int).Rsh(xList[0], uint(xList[0].BitLen()-bits))
+		if got, want := f.Exp(y), new(big.Int).Exp(g, y, n); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for FixedBaseExp.Exp of %d bits", bits)
+		}
+	}
+	if words := (64*50 + _W - 1) / _W; f.TableSize() < words || f.TableSize() > 2*words {
+		t.Errorf("TableSize() = %d after exponents of %d words", f.TableSize(), words)
+	}
+	// an extended table matches a table built at once
+	full := NewPrecomputeTable(g, n, f.TableSize())
+	extended := f.table.Load()
+	for i := 0; i < f.TableSize()*_W; i += 61 {
+		if extended.PowerAt(i).Cmp(full.PowerAt(i)) != 0 {
+			t.Errorf("the extended table differs at bit %d", i)
+		}
+	}
+
+	for _, y := range []*big.Int{nil, big.NewInt(0), big.NewInt(-3)} {
+		if got, want := f.Exp(y), new(big.Int).Exp(g, exponentOrZero(y), n); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for FixedBaseExp.Exp(%v)", y)
+		}
+	}
+	one := NewFixedBaseExp(new(big.Int).Add(n, big1), n)
+	if got := one.Exp(xList[0]); got.Cmp(big1) != 0 || one.TableSize() != 0 {
+		t.Errorf("a base congruent to 1 gave %v with a table of %d rows", got, one.TableSize())
+	}
+	for _, m := range []*big.Int{nil, big.NewInt(10), big.NewInt(-7)} {
+		if NewFixedBaseExp(g, m) != nil {
+			t.Errorf("NewFixedBaseExp accepted m = %v", m)
+		}
+	}
+}
+
+func TestFixedBaseExpConcurrent(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	f := NewFixedBaseExp(g, n)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// each goroutine needs a different table size
+			y := new(big.Int).Rsh(xList[0], uint(i*2000))
+			if got, want := f.Exp(y), new(big.Int).Exp(g, y, n); got.Cmp(want) != 0 {
+				t.Errorf("Wrong result for concurrent FixedBaseExp.Exp %d", i)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,169 @@
+package multiexp
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// fourfoldStateVersion is the first byte of the encoding of a FourfoldState.
+const fourfoldStateVersion = 1
+
+// FourfoldState is a FourfoldExp computation that can be advanced step by step and checkpointed,
+// for exponents so large that the computation takes minutes. It runs the shared squaring loop of
+// multiMontgomery over the 15 chains of fourfoldChains, one exponent word per step.
+// The state is saved with MarshalBinary and restored with UnmarshalBinary, possibly after a restart;
+// the encoding holds Montgomery forms, so it can only be restored on a platform of the same word size.
+// A FourfoldState is not safe for concurrent use.
+type FourfoldState struct {
+	Base      *big.Int
+	Modulus   *big.Int
+	Exponents [4]*big.Int
+	m         nat
+	k0        Word
+	numWords  int
+	chains    [15]nat
+	// topBit is the highest set bit of all the chains, maxWordLen the number of words to process
+	topBit     int
+	maxWordLen int
+	// word is the number of exponent words processed
+	word int
+	// squaredPower is x**(2**(word*_W)) in Montgomery form, z the values of the chains so far
+	squaredPower nat
+	z            [15]nat
+	temp         nat
+}
+
+// FourfoldExpBegin starts a resumable computation of x**y4[i] mod m for the four exponents.
+// A nil exponent is treated as 0. It returns nil if x <= 1, if m is nil, non-positive or even,
+// or if an exponent is negative.
+func FourfoldExpBegin(x, m *big.Int, y4 [4]*big.Int) *FourfoldState {
+	s := &FourfoldState{}
+	if !s.init(x, m, y4) {
+		return nil
+	}
+	power0, power1, _, _ := montgomerySetup(newNat(x), s.m)
+	s.squaredPower = power1
+	for i := range s.z {
+		s.z[i] = nat(nil).set(power0)
+	}
+	return s
+}
+
+// init sets the inputs of s and the values derived from them, and reports whether they are valid.
+func (s *FourfoldState) init(x, m *big.Int, y4 [4]*big.Int) bool {
+	if x == nil || x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return false
+	}
+	var yWords [4]nat
+	for i, y := range y4 {
+		y = exponentOrZero(y)
+		if y.Sign() < 0 {
+			return false
+		}
+		s.Exponents[i] = new(big.Int).Set(y)
+		yWords[i] = newNat(y)
+	}
+	s.Base, s.Modulus = new(big.Int).Set(x), new(big.Int).Set(m)
+	s.m = newNat(m)
+	s.k0, _, s.numWords = montgomeryConstants(s.m)
+	s.chains = fourfoldChains(yWords)
+	s.topBit = maxBitLen(s.chains[:])
+	s.maxWordLen = (s.topBit + _W - 1) / _W
+	s.temp = nat(nil).make(s.numWords)
+	return true
+}
+
+// Step advances the computation by up to nWords exponent words and reports whether more work remains.
+//
+// Step is not a cryptographically constant-time operation.
+func (s *FourfoldState) Step(nWords int) bool {
+	for n := 0; n < nWords && s.word < s.maxWordLen; n++ {
+		s.squaredPower, s.temp = multiMontgomeryWord(s.m, s.k0, s.numWords, s.chains[:], s.z[:],
+			s.squaredPower, s.temp, s.word, s.topBit)
+		s.word++
+	}
+	return s.word < s.maxWordLen
+}
+
+// Result returns x**y4[i] mod m, running the remaining steps first if any.
+// The state is kept, so Result can be called again.
+func (s *FourfoldState) Result() [4]*big.Int {
+	s.Step(maxInt)
+	var ret [4]*big.Int
+	for i := range ret {
+		// assembleAndConvert overwrites its first argument, the state keeps its own
+		zWords := assembleAndConvert(nat(nil).set(s.z[i]), fourfoldAssemblySet(s.z[:], i), s.m, s.k0, s.numWords)
+		ret[i] = new(big.Int).SetBits(zWords.norm().intBits())
+	}
+	return ret
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding the inputs and the progress of s.
+func (s *FourfoldState) MarshalBinary() ([]byte, error) {
+	buf := []byte{fourfoldStateVersion, _W}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(s.word))
+	values := append([]*big.Int{s.Base, s.Modulus}, s.Exponents[:]...)
+	for _, z := range append([]nat{s.squaredPower}, s.z[:]...) {
+		values = append(values, new(big.Int).SetBits(nat(nil).set(z).norm().intBits()))
+	}
+	for _, v := range values {
+		b := v.Bytes()
+		buf = binary.BigEndian.AppendUint64(buf, uint64(len(b)))
+		buf = append(buf, b...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, restoring a state encoded by MarshalBinary
+// on a platform of the same word size.
+func (s *FourfoldState) UnmarshalBinary(data []byte) error {
+	if len(data) < 10 || data[0] != fourfoldStateVersion {
+		return errors.New("multiexp: invalid FourfoldState encoding")
+	}
+	if data[1] != _W {
+		return errors.New("multiexp: FourfoldState encoded with a different word size")
+	}
+	word := binary.BigEndian.Uint64(data[2:10])
+	data = data[10:]
+	values := make([]*big.Int, 2+4+1+15)
+	for i := range values {
+		if len(data) < 8 {
+			return errors.New("multiexp: truncated FourfoldState encoding")
+		}
+		n := binary.BigEndian.Uint64(data)
+		data = data[8:]
+		if n > uint64(len(data)) {
+			return errors.New("multiexp: truncated FourfoldState encoding")
+		}
+		values[i] = new(big.Int).SetBytes(data[:n])
+		data = data[n:]
+	}
+	if len(data) != 0 {
+		return errors.New("multiexp: trailing data in FourfoldState encoding")
+	}
+
+	var restored FourfoldState
+	if !restored.init(values[0], values[1], [4]*big.Int{values[2], values[3], values[4], values[5]}) {
+		return errors.New("multiexp: invalid inputs in FourfoldState encoding")
+	}
+	if word > uint64(restored.maxWordLen) {
+		return errors.New("multiexp: invalid progress in FourfoldState encoding")
+	}
+	restored.word = int(word)
+	for i, v := range values[6:] {
+		// Montgomery forms are below R = 2**(numWords*_W)
+		z := newNat(v)
+		if len(z) > restored.numWords {
+			return errors.New("multiexp: invalid Montgomery form in FourfoldState encoding")
+		}
+		z = append(z, make(nat, restored.numWords-len(z))...)
+		if i == 0 {
+			restored.squaredPower = z
+		} else {
+			restored.z[i-1] = z
+		}
+	}
+	*s = restored
+	return nil
+}
@@ -0,0 +1,89 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFourfoldState(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	y4 := [4]*big.Int{x4[0], new(big.Int).Rsh(x4[1], 1000), nil, x4[3]}
+	var want [4]*big.Int
+	for i := range want {
+		want[i] = new(big.Int).Exp(g, exponentOrZero(y4[i]), n)
+	}
+	check := func(name string, got [4]*big.Int) {
+		for i := range got {
+			if got[i].Cmp(want[i]) != 0 {
+				t.Errorf("%s: wrong result at index %d", name, i)
+			}
+		}
+	}
+
+	s := FourfoldExpBegin(g, n, y4)
+	steps := 1
+	for s.Step(37) {
+		steps++
+		// checkpoint and resume from the encoding after every step
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		s = new(FourfoldState)
+		if err := s.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary: %v", err)
+		}
+	}
+	maxBits := 0
+	for _, y := range y4 {
+		if y != nil && y.BitLen() > maxBits {
+			maxBits = y.BitLen()
+		}
+	}
+	if wantSteps := ((maxBits+_W-1)/_W + 36) / 37; steps != wantSteps {
+		t.Errorf("the computation took %d steps, want %d", steps, wantSteps)
+	}
+	check("after the steps", s.Result())
+	check("Result again", s.Result())
+	check("Result without steps", FourfoldExpBegin(g, n, y4).Result())
+
+	zero := FourfoldExpBegin(g, n, [4]*big.Int{})
+	if zero.Step(1) {
+		t.Errorf("Step reported remaining work for zero exponents")
+	}
+	for i, z := range zero.Result() {
+		if z.Cmp(big1) != 0 {
+			t.Errorf("zero exponent %d gave %v, want 1", i, z)
+		}
+	}
+
+	for _, tc := range []struct {
+		x, m *big.Int
+		y4   [4]*big.Int
+	}{
+		{big1, n, y4},
+		{g, big.NewInt(10), y4},
+		{g, n, [4]*big.Int{big.NewInt(-1)}},
+	} {
+		if FourfoldExpBegin(tc.x, tc.m, tc.y4) != nil {
+			t.Errorf("FourfoldExpBegin accepted x = %v, m = %v, y4 = %v", tc.x, tc.m, tc.y4)
+		}
+	}
+
+	// corrupted encodings are rejected
+	data, _ := FourfoldExpBegin(g, n, y4).MarshalBinary()
+	wrongWord := append([]byte(nil), data...)
+	wrongWord[1] ^= 96
+	for name, d := range map[string][]byte{
+		"empty":       nil,
+		"truncated":   data[:len(data)-1],
+		"trailing":    append(append([]byte(nil), data...), 0),
+		"word size":   wrongWord,
+		"bad version": append([]byte{0}, data[1:]...),
+	} {
+		if err := new(FourfoldState).UnmarshalBinary(d); err == nil {
+			t.Errorf("UnmarshalBinary accepted the %s encoding", name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// ExpAndJacobi computes result = x**y mod |m| together with the Jacobi symbol (result/m),
+// which tells, for a prime m, whether result is a quadratic residue.
+// The Jacobi symbol is computed on the internal representation of the result.
+// ExpAndJacobi panics if m is nil, non-positive or even, for which the Jacobi symbol is not defined.
+// If the result is nil, i.e., y < 0 and x is not invertible mod m, jacobi is 0.
+//
+// ExpAndJacobi is not a cryptographically constant-time operation.
+func ExpAndJacobi(x, y, m *big.Int) (result *big.Int, jacobi int) {
+	if m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		panic("invalid m: the Jacobi symbol needs an odd positive modulus")
+	}
+	mWords := newNat(m)
+	// make sure x > 1 and y is positive, otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 {
+		result = new(big.Int).Exp(x, y, m)
+		if result == nil {
+			return nil, 0
+		}
+		return result, jacobiNat(newNat(result), mWords)
+	}
+	zWords := expNNMontgomery(newNat(x), newNat(y), mWords)
+	return new(big.Int).SetBits(zWords.intBits()), jacobiNat(zWords, mWords)
+}
+
+// jacobiNat returns the Jacobi symbol (x/y) for an odd y, like big.Jacobi for non-negative values.
+func jacobiNat(x, y nat) int {
+	// a and b are the running values, c and r are the scratch space
+	a, b := nat(nil).set(x), nat(nil).set(y)
+	var c, r nat
+	j := 1
+	for {
+		if len(b) == 1 && b[0] == 1 {
+			return j
+		}
+		if len(a) == 0 {
+			return 0
+		}
+		c, r = c.div(r, a, b)
+		a, r = r, a
+		if len(a) == 0 {
+			return 0
+		}
+		// a > 0
+
+		// handle factors of 2 in a
+		s := a.trailingZeroBits()
+		if s&1 != 0 {
+			bmod8 := b[0] & 7
+			if bmod8 == 3 || bmod8 == 5 {
+				j = -j
+			}
+		}
+		c = c.shr(a, s) // a = 2**s*c
+
+		// swap numerator and denominator
+		if b[0]&3 == 3 && c[0]&3 == 3 {
+			j = -j
+		}
+		a, b, c = b, c, a
+	}
+}
@@ -0,0 +1,42 @@
+package multiexp
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestJacobiNat(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		x := new(big.Int).Rand(r, new(big.Int).Lsh(big1, uint(1+r.Intn(300))))
+		y := new(big.Int).Rand(r, new(big.Int).Lsh(big1, uint(1+r.Intn(300))))
+		y.SetBit(y, 0, 1)
+		if got, want := jacobiNat(newNat(x), newNat(y)), big.Jacobi(x, y); got != want {
+			t.Fatalf("jacobiNat(%v, %v) = %d, want %d", x, y, got, want)
+		}
+	}
+}
+
+func TestExpAndJacobi(t *testing.T) {
+	g, _, xList := getBenchParameters(1)
+	p := getPrime256()
+	for _, y := range []*big.Int{xList[0], big.NewInt(2), big.NewInt(1), big.NewInt(0), big.NewInt(-3)} {
+		for _, x := range []*big.Int{g, new(big.Int).Mul(p, big.NewInt(3)), big.NewInt(1)} {
+			want := new(big.Int).Exp(x, y, p)
+			got, jacobi := ExpAndJacobi(x, y, p)
+			if want == nil {
+				if got != nil || jacobi != 0 {
+					t.Errorf("ExpAndJacobi = %v, %d for a non-invertible base, want nil, 0", got, jacobi)
+				}
+				continue
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("Wrong result for ExpAndJacobi")
+			}
+			if wantJacobi := big.Jacobi(want, p); jacobi != wantJacobi {
+				t.Errorf("ExpAndJacobi returned the Jacobi symbol %d, want %d", jacobi, wantJacobi)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// MontContext caches the Montgomery constants of a fixed odd modulus, so that exponentiations
+// of many different bases against the same modulus do not recompute them.
+// A MontContext is safe for concurrent use.
+type MontContext struct {
+	Modulus  *big.Int
+	m        nat
+	k0       Word
+	rr       nat
+	numWords int
+}
+
+// NewMontContext creates the Montgomery context of modulus m.
+// It returns nil if m is nil, non-positive or even.
+func NewMontContext(m *big.Int) *MontContext {
+	if m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return nil
+	}
+	mWords := newNat(m)
+	k0, RR, numWords := montgomeryConstants(mWords)
+	return &MontContext{
+		Modulus:  new(big.Int).Set(m),
+		m:        mWords,
+		k0:       k0,
+		rr:       RR,
+		numWords: numWords,
+	}
+}
+
+// ExpWithMontContext computes x**y mod ctx.Modulus reusing the Montgomery constants cached in ctx.
+// If x <= 1 or y <= 0, the default Exp function of big int is used.
+//
+// ExpWithMontContext is not a cryptographically constant-time operation.
+func ExpWithMontContext(x, y *big.Int, ctx *MontContext) *big.Int {
+	if ctx == nil {
+		panic("montgomery context is nil")
+	}
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 {
+		return new(big.Int).Exp(x, y, ctx.Modulus)
+	}
+	z := ctx.expNN(newNat(x), newNat(y))
+	return new(big.Int).SetBits(z.intBits())
+}
+
+// expNN calculates x**y mod ctx.m using the cached Montgomery constants.
+func (ctx *MontContext) expNN(x, y nat) nat {
+	power0, power1 := montgomeryPowers(x, ctx.m, ctx.k0, ctx.rr, ctx.numWords)
+	z := multiMontgomery(ctx.m, power0, power1, ctx.k0, ctx.numWords, []nat{y})
+	return assembleAndConvert(z[0], nil, ctx.m, ctx.k0, ctx.numWords).norm()
+}
+
+// The functions below work in the Montgomery domain of the context, where x is represented by x*R mod m
+// with R = 2**(_W*len(m)). Their results are almost reduced: below R but possibly not below m, which the
+// Montgomery multiplication accepts as inputs, so chained computations skip the final reductions until FromMont.
+// The inputs must be non-negative and below R.
+
+// ToMont returns the Montgomery form x*R mod m of x, for any non-negative x.
+func (ctx *MontContext) ToMont(x *big.Int) *big.Int {
+	if x.Sign() < 0 {
+		panic("invalid x: negative value")
+	}
+	_, power1 := montgomeryPowers(newNat(x), ctx.m, ctx.k0, ctx.rr, ctx.numWords)
+	return new(big.Int).SetBits(power1.norm().intBits())
+}
+
+// FromMont returns x*R**-1 mod m, fully reduced, for the Montgomery form x, almost reduced or not.
+func (ctx *MontContext) FromMont(x *big.Int) *big.Int {
+	z := assembleAndConvert(ctx.words(x), nil, ctx.m, ctx.k0, ctx.numWords).norm()
+	return new(big.Int).SetBits(z.intBits())
+}
+
+// MontMul sets z to the almost reduced Montgomery product x*y*R**-1 mod m and returns z.
+// z may be x or y, or share their storage: the inputs are copied before z is written.
+func (ctx *MontContext) MontMul(z, x, y *big.Int) *big.Int {
+	zWords := nat(nil).montgomery(ctx.words(x), ctx.words(y), ctx.m, ctx.k0, ctx.numWords)
+	return zWords.norm().setIntBits(z)
+}
+
+// MontSquare sets z to the almost reduced Montgomery square x*x*R**-1 mod m and returns z.
+// z may be x, or share its storage: the input is copied before z is written.
+func (ctx *MontContext) MontSquare(z, x *big.Int) *big.Int {
+	xWords := ctx.words(x)
+	zWords := nat(nil).montgomery(xWords, xWords, ctx.m, ctx.k0, ctx.numWords)
+	return zWords.norm().setIntBits(z)
+}
+
+// ExpMont returns the almost reduced Montgomery form of x**y mod m for the Montgomery form x,
+// i.e., it is ExpWithMontContext without the conversions and the final reduction. y must be non-negative.
+//
+// ExpMont is not a cryptographically constant-time operation.
+func (ctx *MontContext) ExpMont(x, y *big.Int) *big.Int {
+	if y.Sign() < 0 {
+		panic("invalid y: negative value")
+	}
+	power0 := power0Of(ctx.rr, ctx.m, ctx.k0, ctx.numWords)
+	z := multiMontgomery(ctx.m, power0, ctx.words(x), ctx.k0, ctx.numWords, []nat{newNat(y)})
+	return new(big.Int).SetBits(z[0].norm().intBits())
+}
+
+// words returns a copy of the Montgomery form x padded to the length of m.
+// Working on copies makes the exported functions alias-safe, unlike montgomery,
+// whose result must not alias its inputs.
+func (ctx *MontContext) words(x *big.Int) nat {
+	if x.Sign() < 0 || len(x.Bits()) > ctx.numWords {
+		panic("invalid montgomery form: not in [0, R)")
+	}
+	z := nat(nil).make(ctx.numWords)
+	z.clear()
+	copy(z, newNat(x))
+	return z
+}
@@ -0,0 +1,129 @@
+package multiexp
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func TestExpWithMontContext(t *testing.T) {
+	_, n, _ := getBenchParameters(1)
+	ctx := NewMontContext(n)
+	if ctx == nil {
+		t.Fatal("NewMontContext returned nil for an odd modulus")
+	}
+	for i := 0; i < 10; i++ {
+		x, err := rand.Int(rand.Reader, getBenchGroupLimit())
+		if err != nil {
+			t.Fatal(err)
+		}
+		y, err := rand.Int(rand.Reader, getBenchRandLimit())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := ExpWithMontContext(x, y, ctx), new(big.Int).Exp(x, y, n); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpWithMontContext")
+		}
+	}
+	// the default path
+	if got := ExpWithMontContext(big.NewInt(5), big.NewInt(0), ctx); got.Cmp(big1) != 0 {
+		t.Errorf("ExpWithMontContext(5, 0) = %v, want 1", got)
+	}
+	if NewMontContext(big.NewInt(10)) != nil {
+		t.Errorf("NewMontContext accepted an even modulus")
+	}
+}
+
+func TestExpWithMontContextLargeBase(t *testing.T) {
+	_, n, xList := getBenchParameters(1)
+	ctx := NewMontContext(n)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// bases longer than the modulus are reduced in the pooled buffers
+			for j := 0; j < 5; j++ {
+				x := new(big.Int).Lsh(n, uint(64*(i+j)+1))
+				x.Add(x, big.NewInt(int64(3+i+j)))
+				if got, want := ExpWithMontContext(x, xList[0], ctx), new(big.Int).Exp(x, xList[0], n); got.Cmp(want) != 0 {
+					t.Errorf("Wrong result for ExpWithMontContext with a base longer than the modulus")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestMontContextDomain(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	ctx := NewMontContext(n)
+	a, b := new(big.Int).Mod(g, n), new(big.Int).Sub(n, big1)
+	aMont, bMont := ctx.ToMont(a), ctx.ToMont(b)
+	if got := ctx.FromMont(aMont); got.Cmp(a) != 0 {
+		t.Errorf("FromMont(ToMont(a)) != a")
+	}
+
+	// (a*b)**y * b**2 chained in the Montgomery domain, converted once at the end
+	z := ctx.MontMul(new(big.Int), aMont, bMont)
+	z = ctx.ExpMont(z, xList[0])
+	sq := ctx.MontSquare(new(big.Int), bMont)
+	z = ctx.MontMul(z, z, sq)
+	want := new(big.Int).Mul(a, b)
+	want.Exp(want, xList[0], n)
+	want.Mul(want, new(big.Int).Mul(b, b)).Mod(want, n)
+	if got := ctx.FromMont(z); got.Cmp(want) != 0 {
+		t.Errorf("Wrong result for the chained Montgomery computation")
+	}
+	if got := ctx.FromMont(ctx.ExpMont(aMont, big.NewInt(0))); got.Cmp(big1) != 0 {
+		t.Errorf("ExpMont(a, 0) = %v in plain form, want 1", got)
+	}
+
+	// an almost reduced input in [m, R)
+	if almost := new(big.Int).Add(aMont, n); len(almost.Bits()) == len(n.Bits()) {
+		want := new(big.Int).Mul(a, b)
+		want.Mod(want, n)
+		if got := ctx.FromMont(ctx.MontMul(new(big.Int), almost, bMont)); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for MontMul with an almost reduced input")
+		}
+	}
+}
+
+func TestMontContextAliasing(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	ctx := NewMontContext(n)
+	a := ctx.ToMont(new(big.Int).Mod(g, n))
+	b := ctx.ToMont(big.NewInt(12345))
+	wantAB := ctx.MontMul(new(big.Int), a, b)
+	wantAA := ctx.MontSquare(new(big.Int), a)
+
+	// z is x, z is y, z is both, and z only shares the storage of x
+	z := new(big.Int).Set(a)
+	if ctx.MontMul(z, z, b); z.Cmp(wantAB) != 0 {
+		t.Errorf("Wrong result for MontMul with z aliasing x")
+	}
+	z.Set(b)
+	if ctx.MontMul(z, a, z); z.Cmp(wantAB) != 0 {
+		t.Errorf("Wrong result for MontMul with z aliasing y")
+	}
+	z.Set(a)
+	if ctx.MontMul(z, z, z); z.Cmp(wantAA) != 0 {
+		t.Errorf("Wrong result for MontMul with z aliasing x and y")
+	}
+	x := new(big.Int).Set(a)
+	shared := new(big.Int).SetBits(x.Bits())
+	if ctx.MontMul(shared, x, b); shared.Cmp(wantAB) != 0 {
+		t.Errorf("Wrong result for MontMul with z sharing the storage of x")
+	}
+
+	z.Set(a)
+	if ctx.MontSquare(z, z); z.Cmp(wantAA) != 0 {
+		t.Errorf("Wrong result for MontSquare with z aliasing x")
+	}
+	x.Set(a)
+	shared = new(big.Int).SetBits(x.Bits())
+	if ctx.MontSquare(shared, x); shared.Cmp(wantAA) != 0 {
+		t.Errorf("Wrong result for MontSquare with z sharing the storage of x")
+	}
+}
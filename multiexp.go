@@ -2,15 +2,21 @@ package multiexp
 
 import (
 	"context"
-	"fmt"
 	"math/big"
+	"runtime"
 )
 
 const defaultWordChunkSize = 2
 
+// nfoldWindowWidth is the window width of the shared loop of NfoldExp: on dense exponents most of the gain
+// over the bit-by-bit loop is reached at width 4, with half of the buckets of width 5, see BenchmarkDenseWindowed.
+const nfoldWindowWidth = 4
+
 var (
 	big1  = big.NewInt(1)
 	masks = [_W]Word{}
+	// fourfoldWantAll marks all the four outputs of the fourfold functions as wanted
+	fourfoldWantAll = [4]bool{true, true, true, true}
 )
 
 func init() {
@@ -22,12 +28,17 @@ func init() {
 // DoubleExp sets z1 = x**y1 mod |m|, z2 = x**y2 mod |m| ... (i.e. the sign of m is ignored), and returns z1, z2.
 // If m == nil or m == 0, z = x**y unless y <= 0 then z = 1. If m != 0, y < 0,
 // and x and m are not relatively prime, z is unchanged and nil is returned.
+// A nil exponent is treated as 0. Like the other entry points of this package, the inputs outside the
+// fast path, x <= 1, y <= 0, or m nil, non-positive or even, get the results of the default Exp function.
 //
 // DoubleExp is not a cryptographically constant-time operation.
 func DoubleExp(x *big.Int, y2 [2]*big.Int, m *big.Int) [2]*big.Int {
+	for i := range y2 {
+		y2[i] = exponentOrZero(y2[i])
+	}
 	// make sure x > 1, m is not nil, and m > 0, otherwise, use default Exp function
 	if x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 {
-		return defaultExp2(x, m, [2]*big.Int{y2[0], y2[1]})
+		return defaultExp2(x, m, y2)
 	}
 	// make sure y1 and y2 are positive
 	if y2[0].Sign() <= 0 || y2[1].Sign() <= 0 {
@@ -44,7 +55,6 @@ func DoubleExp(x *big.Int, y2 [2]*big.Int, m *big.Int) [2]*big.Int {
 // defaultExp2 uses the default Exp function of big int to handle the edge cases that cannot be handled by DoubleExp in
 // this library or cannot benefit from this library in terms of performance
 func defaultExp2(x, m *big.Int, y2 [2]*big.Int) [2]*big.Int {
-	fmt.Println("something wrong here, get into defaultExp2")
 	var ret [2]*big.Int
 	for i := range y2 {
 		ret[i] = new(big.Int).Exp(x, y2[i], m)
@@ -52,6 +62,14 @@ func defaultExp2(x, m *big.Int, y2 [2]*big.Int) [2]*big.Int {
 	return ret
 }
 
+// exponentOrZero returns y, or 0 for a nil y: the entry points treat nil exponents as 0.
+func exponentOrZero(y *big.Int) *big.Int {
+	if y == nil {
+		return new(big.Int)
+	}
+	return y
+}
+
 // defaultExp4 uses the default Exp function of big int to handle the edge cases that cannot be handled by FourfoldExp in
 // this library or cannot benefit from this library in terms of performance
 func defaultExp4(x, m *big.Int, y4 [4]*big.Int) [4]*big.Int {
@@ -88,21 +106,9 @@ func doubleExpNNMontgomery(x, y1, y2, m nat) [2]*big.Int {
 	for i := range mmValues {
 		// One last reduction, just in case.
 		// See golang.org/issue/13907.
-		if mmValues[i].cmp(m) >= 0 {
-			// Common case is m has high bit set; in that case,
-			// since zz is the same length as m, there can be just
-			// one multiple of m to remove. Just subtract.
-			// We think that the subtraction should be sufficient in general,
-			// so do that unconditionally, but double-check,
-			// in case our beliefs are wrong.
-			// The div is not expected to be reached.
-			mmValues[i] = mmValues[i].sub(mmValues[i], m)
-			if mmValues[i].cmp(m) >= 0 {
-				_, mmValues[i] = nat(nil).div(nil, mmValues[i], m)
-			}
-		}
+		mmValues[i] = finalReduce(mmValues[i], m)
 		// final normalization
-		mmValues[i].norm()
+		mmValues[i] = mmValues[i].norm()
 		ret[i] = new(big.Int).SetBits(mmValues[i].intBits())
 	}
 
@@ -110,19 +116,16 @@ func doubleExpNNMontgomery(x, y1, y2, m nat) [2]*big.Int {
 }
 
 func montgomerySetup(x, m nat) (power0, power1 nat, k0 Word, numWords int) {
-	numWords = len(m)
+	var RR nat
+	k0, RR, numWords = montgomeryConstants(m)
+	power0, power1 = montgomeryPowers(x, m, k0, RR, numWords)
+	return
+}
 
-	// We want the lengths of x and m to be equal.
-	// It is OK if x >= m as long as len(x) == len(m).
-	if len(x) > numWords {
-		_, x = nat(nil).div(nil, x, m)
-		// Note: now len(x) <= numWords, not guaranteed ==.
-	}
-	if len(x) < numWords {
-		rr := make(nat, numWords)
-		copy(rr, x)
-		x = rr
-	}
+// montgomeryConstants computes the constants that only depend on the modulus m:
+// k0 = -m**-1 mod 2**_W, RR = 2**(2*_W*len(m)) mod m and the number of words of m.
+func montgomeryConstants(m nat) (k0 Word, RR nat, numWords int) {
+	numWords = len(m)
 
 	// Ideally the pre-computations would be performed outside, and reused
 	// k0 = -m**-1 mod 2**_W. Algorithm from: Dumas, J.G. "On Newton–Raphson
@@ -134,15 +137,38 @@ func montgomerySetup(x, m nat) (power0, power1 nat, k0 Word, numWords int) {
 		k0 *= t + 1
 	}
 	k0 = -k0
+	// an even m has no inverse, its k0 is meaningless and only the fallbacks use it
+	if checkResults && m[0]&1 == 1 && m[0]*-k0 != 1 {
+		panic("multiexp: k0 is not -m**-1 mod 2**_W")
+	}
+
+	// RR = 2**(2*_W*len(m)) mod m, divided in the pooled buffers, only RR is allocated
+	s := getDivScratch()
+	s.r = s.r.setWord(1)
+	s.u = s.u.shl(s.r, uint(2*numWords*_W))
+	s.q, s.r = s.q.div(s.r, s.u, m)
+	RR = make(nat, numWords)
+	copy(RR, s.r)
+	putDivScratch(s)
+	return
+}
 
-	// RR = 2**(2*_W*len(m)) mod m
-	RR := nat(nil).setWord(1)
-	zz1 := nat(nil).shl(RR, uint(2*numWords*_W))
-	_, RR = nat(nil).div(RR, zz1, m)
-	if len(RR) < numWords {
-		zz1 = zz1.make(numWords)
-		copy(zz1, RR)
-		RR = zz1
+// montgomeryPowers converts x**0 and x**1 into the Montgomery representation with respect to m.
+func montgomeryPowers(x, m nat, k0 Word, RR nat, numWords int) (power0, power1 nat) {
+	// We want the lengths of x and m to be equal.
+	// It is OK if x >= m as long as len(x) == len(m).
+	if len(x) > numWords {
+		// reduce x in the pooled buffers, now len(x) <= numWords, not guaranteed ==
+		s := getDivScratch()
+		s.q, s.r = s.q.div(s.r, x, m)
+		x = make(nat, numWords)
+		copy(x, s.r)
+		putDivScratch(s)
+	}
+	if len(x) < numWords {
+		rr := make(nat, numWords)
+		copy(rr, x)
+		x = rr
 	}
 
 	// one = 1, with equal length to that of m
@@ -158,6 +184,13 @@ func montgomerySetup(x, m nat) (power0, power1 nat, k0 Word, numWords int) {
 
 // multiMontgomery calculates the modular montgomery exponent with result not normalized
 func multiMontgomery(m, power0, power1 nat, k0 Word, numWords int, yList []nat) []nat {
+	return multiMontgomeryWithProgress(m, power0, power1, k0, numWords, yList, nil)
+}
+
+// multiMontgomeryWithProgress is multiMontgomery reporting the number of exponent words processed so far to
+// progress after each word of the shared squaring loop. A nil progress disables the reporting.
+func multiMontgomeryWithProgress(m, power0, power1 nat, k0 Word, numWords int, yList []nat,
+	progress func(done, total int)) []nat {
 	// initialize each value to be 1 (Montgomery 1)
 	zList := make([]nat, len(yList))
 	for i := range zList {
@@ -169,33 +202,143 @@ func multiMontgomery(m, power0, power1 nat, k0 Word, numWords int, yList []nat)
 	copy(squaredPower, power1)
 	//	fmt.Println("squaredPower = ", squaredPower.String())
 
-	maxWordLen := 1
+	// the loop stops at the highest set bit of all the exponents, whatever their lengths in words
+	topBit := maxBitLen(yList)
+	maxWordLen := (topBit + _W - 1) / _W
+
+	temp := nat(nil).make(numWords)
+	for i := 0; i < maxWordLen; i++ {
+		squaredPower, temp = multiMontgomeryWord(m, k0, numWords, yList, zList, squaredPower, temp, i, topBit)
+		if progress != nil {
+			progress(i+1, maxWordLen)
+		}
+	}
+
+	return zList
+}
+
+// multiMontgomeryWord runs the shared squaring loop of multiMontgomery over the word i of the exponents:
+// for each bit, zList[k] is multiplied by squaredPower if the bit of yList[k] is set, then squaredPower is
+// squared, up to the highest set bit topBit of all the exponents. It returns the new squaredPower and temp,
+// swapped with each other by the products.
+func multiMontgomeryWord(m nat, k0 Word, numWords int, yList, zList []nat, squaredPower, temp nat,
+	i, topBit int) (nat, nat) {
+	for j := 0; j < _W; j++ {
+		for k := range yList {
+			if len(yList[k]) <= i {
+				continue
+			}
+			if (yList[k][i] & masks[j]) != masks[j] {
+				continue
+			}
+			temp = temp.montgomery(zList[k], squaredPower, m, k0, numWords)
+			zList[k], temp = temp, zList[k]
+		}
+		// no squaring is needed after the highest set bit
+		if i*_W+j+1 == topBit {
+			break
+		}
+		// montgomery must have the returned value not same as the input values
+		// we have to use this temp as the middle variable
+		temp = temp.montgomery(squaredPower, squaredPower, m, k0, numWords)
+		squaredPower, temp = temp, squaredPower
+	}
+	return squaredPower, temp
+}
+
+// maxBitLen returns the highest bit length among the exponents.
+func maxBitLen(yList []nat) int {
+	topBit := 0
 	for i := range yList {
-		if len(yList[i]) > maxWordLen {
-			maxWordLen = len(yList[i])
+		if l := yList[i].bitLen(); l > topBit {
+			topBit = l
 		}
 	}
+	return topBit
+}
 
+// multiMontgomeryWindowed is multiMontgomeryWithProgress scanning the exponents width bits at a time.
+// The squarings of x are still shared, while each exponent collects x**(2**(width*i)) into a bucket
+// per window value (Yao's method); the buckets form a transient window table combined once at the end.
+// A table of small odd powers of x shared by the exponents would need a left-to-right scan, squaring
+// each exponent's accumulator on its own, which is much slower, see BenchmarkFourfoldChainsLeftToRight.
+// The buckets only pay off on dense exponents, see nfoldWindowWidth; on the sparse fourfold chains they
+// gain nothing and allocate more, see BenchmarkFourfoldChainsWindowed, so the fourfold functions keep multiMontgomery.
+func multiMontgomeryWindowed(m, power0, power1 nat, k0 Word, numWords int, yList []nat, width int,
+	progress func(done, total int)) []nat {
+	topBit := maxBitLen(yList)
+	maxWordLen := (topBit + _W - 1) / _W
+	numWindows := (topBit + width - 1) / width
+
+	// buckets[k][d] is the product of the powers whose window in yList[k] is d, nil stands for 1
+	buckets := make([][]nat, len(yList))
+	for k := range buckets {
+		buckets[k] = make([]nat, 1<<uint(width))
+	}
+
+	squaredPower := nat(nil).make(numWords)
+	copy(squaredPower, power1)
 	temp := nat(nil).make(numWords)
-	for i := 0; i < maxWordLen; i++ {
-		for j := 0; j < _W; j++ {
-			for k := range yList {
-				if len(yList[k]) <= i {
-					continue
-				}
-				if (yList[k][i] & masks[j]) != masks[j] {
-					continue
-				}
-				temp = temp.montgomery(zList[k], squaredPower, m, k0, numWords)
-				zList[k], temp = temp, zList[k]
+	for i := 0; i < numWindows; i++ {
+		for k := range yList {
+			d := yList[k].window(i*width, width)
+			if d == 0 {
+				continue
+			}
+			if buckets[k][d] == nil {
+				buckets[k][d] = nat(nil).make(numWords)
+				copy(buckets[k][d], squaredPower)
+				continue
 			}
-			// montgomery must have the returned value not same as the input values
-			// we have to use this temp as the middle variable
+			temp = temp.montgomery(buckets[k][d], squaredPower, m, k0, numWords)
+			buckets[k][d], temp = temp, buckets[k][d]
+		}
+		if done := (i + 1) * width / _W; progress != nil && (done > i*width/_W || i == numWindows-1) {
+			if i == numWindows-1 {
+				done = maxWordLen
+			}
+			progress(done, maxWordLen)
+		}
+		if i == numWindows-1 {
+			break
+		}
+		for j := 0; j < width; j++ {
 			temp = temp.montgomery(squaredPower, squaredPower, m, k0, numWords)
 			squaredPower, temp = temp, squaredPower
 		}
 	}
 
+	// z = prod buckets[d]**d, computed as the product of the running products from the top bucket down
+	zList := make([]nat, len(yList))
+	for k := range yList {
+		var acc, z nat
+		for d := len(buckets[k]) - 1; d > 0; d-- {
+			if b := buckets[k][d]; b != nil {
+				if acc == nil {
+					acc = b
+				} else {
+					temp = temp.montgomery(acc, b, m, k0, numWords)
+					// acc is either a consumed bucket or a previous temp, so it can be reused
+					acc, temp = temp, acc
+				}
+			}
+			if acc == nil {
+				continue
+			}
+			if z == nil {
+				z = nat(nil).make(numWords)
+				copy(z, acc)
+				continue
+			}
+			temp = temp.montgomery(z, acc, m, k0, numWords)
+			z, temp = temp, z
+		}
+		if z == nil {
+			z = nat(nil).make(numWords)
+			copy(z, power0)
+		}
+		zList[k] = z
+	}
 	return zList
 }
 
@@ -238,10 +381,15 @@ func multiMontgomeryPrecomputed(m, power0 nat, k0 Word,
 }
 
 // FourfoldExp sets z1 = x**y1 mod |m|, z2 = x**y2 mod |m| ... (i.e. the sign of m is ignored), and returns z1, z2...
-// In construction, many panic conditions. Use at your own risk!
+// The inputs are handled like in DoubleExp.
+// Repeated exponents are computed once: four identical exponents take a single exponentiation,
+// and two or three distinct ones the sharing of DoubleExp or of the fourfold chains over them only.
 //
 // FourfoldExp is not a cryptographically constant-time operation.
 func FourfoldExp(x, m *big.Int, y4 [4]*big.Int) [4]*big.Int {
+	for i := range y4 {
+		y4[i] = exponentOrZero(y4[i])
+	}
 	// make sure x > 1, m is not nil, and m > 0, otherwise, use default Exp function
 	if x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 {
 		return defaultExp4(x, m, y4)
@@ -260,61 +408,247 @@ func FourfoldExp(x, m *big.Int, y4 [4]*big.Int) [4]*big.Int {
 	return fourfoldExpNNMontgomery(xWords, mWords, y4)
 }
 
+// FourfoldExpWithProgress is like FourfoldExp but calls progress as the shared squaring loop advances through the
+// exponent words, with done the number of words processed and total the word length of the longest exponent.
+// progress is called synchronously from the calling goroutine; a nil progress disables the reporting.
+// It is not called when the inputs are handled by the default Exp function.
+//
+// FourfoldExpWithProgress is not a cryptographically constant-time operation.
+func FourfoldExpWithProgress(x, m *big.Int, y4 [4]*big.Int, progress func(done, total int)) [4]*big.Int {
+	for i := range y4 {
+		y4[i] = exponentOrZero(y4[i])
+	}
+	// make sure x > 1, m is not nil, m > 0, m is odd and all the y4 elements are positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return defaultExp4(x, m, y4)
+	}
+	for i := range y4 {
+		if y4[i].Sign() <= 0 {
+			return defaultExp4(x, m, y4)
+		}
+	}
+	converted := fourfoldExpNNMontgomeryNat(newNat(x), newNat(m), y4, fourfoldWantAll, progress)
+	var ret [4]*big.Int
+	for i := range ret {
+		converted[i] = converted[i].norm()
+		ret[i] = new(big.Int).SetBits(converted[i].intBits())
+	}
+	return ret
+}
+
+// FourfoldExpSubset is like FourfoldExp but only returns the results marked in want; the other slots are left nil.
+// The common words of all four exponents are still shared, so the unwanted slots only save their final assembly.
+// Unwanted slots of y4 are placeholders: nil or non-positive values are treated as 0.
+//
+// FourfoldExpSubset is not a cryptographically constant-time operation.
+func FourfoldExpSubset(x, m *big.Int, y4 [4]*big.Int, want [4]bool) [4]*big.Int {
+	var ys [4]*big.Int
+	useDefault := x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1
+	for i := range y4 {
+		if !want[i] {
+			// placeholders contribute nothing to the shared chains
+			ys[i] = new(big.Int)
+			if y4[i] != nil && y4[i].Sign() > 0 {
+				ys[i] = y4[i]
+			}
+			continue
+		}
+		ys[i] = exponentOrZero(y4[i])
+		if ys[i].Sign() <= 0 {
+			useDefault = true
+		}
+	}
+
+	var ret [4]*big.Int
+	if useDefault {
+		for i := range y4 {
+			if want[i] {
+				ret[i] = new(big.Int).Exp(x, ys[i], m)
+			}
+		}
+		return ret
+	}
+	converted := fourfoldExpNNMontgomeryNat(newNat(x), newNat(m), ys, want, nil)
+	for i := range ret {
+		if want[i] {
+			converted[i] = converted[i].norm()
+			ret[i] = new(big.Int).SetBits(converted[i].intBits())
+		}
+	}
+	return ret
+}
+
+// MergeDouble merges the exponents of two DoubleExp calls with the same base and modulus into the input of
+// a single FourfoldExp call, which returns a[0], a[1], b[0], b[1] in this order.
+// When the four exponents share bits, the fourfold call is faster than the two DoubleExp calls since the
+// shared bits are multiplied only once.
+func MergeDouble(a, b [2]*big.Int) [4]*big.Int {
+	return [4]*big.Int{a[0], a[1], b[0], b[1]}
+}
+
+// BatchExp computes x**ys[i] mod |m| for all the exponents, grouping them by four into FourfoldExp calls.
+// The last group is completed with placeholders that cost no multiplication.
+// An empty ys gives an empty, non-nil result slice.
+//
+// BatchExp is not a cryptographically constant-time operation.
+func BatchExp(x, m *big.Int, ys []*big.Int) []*big.Int {
+	ret := make([]*big.Int, len(ys))
+	for i := 0; i < len(ys); i += 4 {
+		var y4 [4]*big.Int
+		var want [4]bool
+		for j := range y4 {
+			if i+j < len(ys) {
+				y4[j], want[j] = ys[i+j], true
+			}
+		}
+		z4 := FourfoldExpSubset(x, m, y4, want)
+		copy(ret[i:], z4[:])
+	}
+	return ret
+}
+
+// FourfoldExpInto is like FourfoldExp but writes the results into the caller-provided dst instead of allocating
+// new big.Ints. The elements of dst must be non-nil and are overwritten; their existing backing arrays are reused
+// when they are large enough.
+//
+// FourfoldExpInto is not a cryptographically constant-time operation.
+func FourfoldExpInto(dst *[4]*big.Int, x, m *big.Int, y4 [4]*big.Int) {
+	for i := range dst {
+		if dst[i] == nil {
+			panic("invalid dst: nil element")
+		}
+	}
+	// make sure x > 1, m is not nil, m > 0, m is odd and all the y4 elements are positive,
+	// otherwise, use default Exp function
+	useDefault := x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1
+	for i := range y4 {
+		y4[i] = exponentOrZero(y4[i])
+		if y4[i].Sign() <= 0 {
+			useDefault = true
+		}
+	}
+	if useDefault {
+		ret := defaultExp4(x, m, y4)
+		for i := range dst {
+			dst[i].Set(ret[i])
+		}
+		return
+	}
+	converted := fourfoldExpNNMontgomeryNat(newNat(x), newNat(m), y4, fourfoldWantAll, nil)
+	for i := range dst {
+		converted[i].norm().setIntBits(dst[i])
+	}
+}
+
 // fourfoldExpNNMontgomery calculates x**y1 mod m and x**y2 mod m x**y3 mod m and x**y4 mod m
 // Uses Montgomery representation.
 func fourfoldExpNNMontgomery(x, m nat, y [4]*big.Int) [4]*big.Int {
+	// repeated exponents are computed once, by the cheapest path for the number of distinct ones
+	distinct, slots := fourfoldDistinct(y)
+	var results []*big.Int
+	switch len(distinct) {
+	case 1:
+		zWords := expNNMontgomery(x, newNat(distinct[0]), m)
+		results = []*big.Int{new(big.Int).SetBits(zWords.intBits())}
+	case 2:
+		z2 := doubleExpNNMontgomery(x, newNat(distinct[0]), newNat(distinct[1]), m)
+		results = z2[:]
+	case 3:
+		// the placeholder 0 adds nothing to the shared chains and is not assembled
+		y3 := [4]*big.Int{distinct[0], distinct[1], distinct[2], new(big.Int)}
+		converted := fourfoldExpNNMontgomeryNat(x, m, y3, [4]bool{true, true, true, false}, nil)
+		for i := range distinct {
+			results = append(results, new(big.Int).SetBits(converted[i].norm().intBits()))
+		}
+	default:
+		converted := fourfoldExpNNMontgomeryNat(x, m, y, fourfoldWantAll, nil)
+		for i := range converted {
+			results = append(results, new(big.Int).SetBits(converted[i].norm().intBits()))
+		}
+	}
+	var ret [4]*big.Int
+	for i := range ret {
+		// the slots of a repeated exponent get their own copies
+		ret[i] = new(big.Int).Set(results[slots[i]])
+	}
+	return ret
+}
+
+// fourfoldDistinct returns the distinct values among y, in the order of their first slots,
+// and for each slot of y the index of its value in distinct.
+func fourfoldDistinct(y [4]*big.Int) (distinct []*big.Int, slots [4]int) {
+	for i := range y {
+		slots[i] = len(distinct)
+		for j := range distinct {
+			if y[i].Cmp(distinct[j]) == 0 {
+				slots[i] = j
+				break
+			}
+		}
+		if slots[i] == len(distinct) {
+			distinct = append(distinct, y[i])
+		}
+	}
+	return distinct, slots
+}
+
+// fourfoldExpNNMontgomeryNat is fourfoldExpNNMontgomery with the results left as reduced but not normalized nats.
+// Only the outputs marked in want are assembled, the others are left nil.
+func fourfoldExpNNMontgomeryNat(x, m nat, y [4]*big.Int, want [4]bool, progress func(done, total int)) [4]nat {
 	power0, power1, k0, numWords := montgomerySetup(x, m)
 	// Zero round, find common bits of the four values
 	//fmt.Println("test here, len = ", len([]nat{y[0].abs, y[1].abs, y[2].abs, y[3].abs}))
-	gcwList := fourfoldGCW([4]nat{newNat(y[0]), newNat(y[1]), newNat(y[2]), newNat(y[3])})
-	// First round, find common bits of the three values
-	var cm012, cm013, cm023, cm123 nat
-	cm012 = threefoldGCW([3]nat{gcwList[0], gcwList[1], gcwList[2]})
-	cm013 = threefoldGCW([3]nat{gcwList[0], gcwList[1], gcwList[3]})
-	cm023 = threefoldGCW([3]nat{gcwList[0], gcwList[2], gcwList[3]})
-	cm123 = threefoldGCW([3]nat{gcwList[1], gcwList[2], gcwList[3]})
-
-	var cm01, cm23, cm02, cm13, cm03, cm12 nat
-	gcwList[0], gcwList[1], cm01 = gcw(gcwList[0], gcwList[1])
-	gcwList[2], gcwList[3], cm23 = gcw(gcwList[2], gcwList[3])
-	gcwList[0], gcwList[2], cm02 = gcw(gcwList[0], gcwList[2])
-	gcwList[1], gcwList[3], cm13 = gcw(gcwList[1], gcwList[3])
-	gcwList[0], gcwList[3], cm03 = gcw(gcwList[0], gcwList[3])
-	gcwList[1], gcwList[2], cm12 = gcw(gcwList[1], gcwList[2])
-
-	z := multiMontgomery(m, power0, power1, k0, numWords,
-		//      0-4      	  5     6      7       8     9     10     11    12    13    14
-		append(gcwList[:], cm012, cm013, cm023, cm123, cm01, cm23, cm02, cm13, cm03, cm12),
-	)
+	chains := fourfoldChains([4]nat{newNat(y[0]), newNat(y[1]), newNat(y[2]), newNat(y[3])})
+	z := multiMontgomeryWithProgress(m, power0, power1, k0, numWords, chains[:], progress)
 
 	// calculate the actual values
 	var converted [4]nat
-	converted[0] = assembleAndConvert(z[0], []nat{z[4], z[5], z[6], z[7], z[9], z[11], z[13]}, m, k0, numWords)
-	converted[1] = assembleAndConvert(z[1], []nat{z[4], z[5], z[6], z[8], z[9], z[12], z[14]}, m, k0, numWords)
-	converted[2] = assembleAndConvert(z[2], []nat{z[4], z[5], z[7], z[8], z[10], z[11], z[14]}, m, k0, numWords)
-	converted[3] = assembleAndConvert(z[3], []nat{z[4], z[6], z[7], z[8], z[10], z[12], z[13]}, m, k0, numWords)
+	for i := range converted {
+		if want[i] {
+			converted[i] = assembleAndConvert(z[i], fourfoldAssemblySet(z, i), m, k0, numWords)
+		}
+	}
+	return converted
+}
 
-	var ret [4]*big.Int
-	// normalize and set value
-	for i := range ret {
-		converted[i].norm()
-		ret[i] = new(big.Int).SetBits(converted[i].intBits())
+// ExpWithCoResidue computes full = x**y mod |m| and residue = full mod d with a single exponentiation.
+// If d divides m, residue equals x**y mod d. d need not divide m, in which case residue is just full reduced mod d.
+// A nil y is treated as 0. ExpWithCoResidue panics if d is nil or not positive.
+//
+// ExpWithCoResidue is not a cryptographically constant-time operation.
+func ExpWithCoResidue(x, y, m, d *big.Int) (full, residue *big.Int) {
+	if d == nil || d.Sign() <= 0 {
+		panic("invalid d: non-positive value")
 	}
-	return ret
+	y = exponentOrZero(y)
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		full = new(big.Int).Exp(x, y, m)
+		return full, new(big.Int).Mod(full, d)
+	}
+	zWords := expNNMontgomery(newNat(x), newNat(y), newNat(m))
+	_, rWords := nat(nil).div(nil, zWords, newNat(d))
+	full = new(big.Int).SetBits(zWords.intBits())
+	residue = new(big.Int).SetBits(rWords.norm().intBits())
+	return full, residue
+}
+
+// expNNMontgomery calculates x**y mod m
+// Uses Montgomery representation.
+func expNNMontgomery(x, y, m nat) nat {
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	z := multiMontgomery(m, power0, power1, k0, numWords, []nat{y})
+	return assembleAndConvert(z[0], nil, m, k0, numWords).norm()
 }
 
 // ExpParallel computes x ** y mod |m| utilizing multiple CPU cores
 // numRoutine specifies the number of routine for computing the result
+// With a single routine or GOMAXPROCS(0) == 1, the result is computed in the calling goroutine.
 func ExpParallel(x, y, m *big.Int, preTable *PreTable, numRoutine, wordChunkSize int) *big.Int {
-	if preTable == nil {
-		panic("precompute table is nil")
-	}
-	if preTable.Base.Cmp(x) != 0 {
-		panic("precompute table not match: invalid base")
-	}
-	if preTable.Modulus.Cmp(m) != 0 {
-		panic("precompute table not match: invalid modulus")
-	}
+	checkPreTable(preTable, x, m)
+	y = exponentOrZero(y)
 	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
 	// otherwise, use default Exp function
 	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
@@ -327,6 +661,11 @@ func ExpParallel(x, y, m *big.Int, preTable *PreTable, numRoutine, wordChunkSize
 		wordChunkSize = defaultWordChunkSize
 	}
 	xWords, yWords, mWords := newNat(x), newNat(y), newNat(m)
+	// a single routine or a single core gains nothing from the goroutines
+	if numRoutine == 1 || runtime.GOMAXPROCS(0) == 1 {
+		zWords := expNNMontgomeryPrecomputed(xWords, yWords, mWords, preTable)
+		return new(big.Int).SetBits(zWords.intBits())
+	}
 	zWords := expNNMontgomeryPrecomputedParallel(xWords, yWords, mWords, preTable, numRoutine, wordChunkSize)
 	return new(big.Int).SetBits(zWords.intBits())
 }
@@ -335,7 +674,7 @@ func expNNMontgomeryPrecomputedParallel(x, y, m nat, table *PreTable, numRoutine
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	power0, _, k0, numWords := montgomerySetup(x, m)
+	power0, k0, numWords := table.montgomerySetup(m)
 
 	numPivots := len(y) / wordChunkSize
 	if len(y)%wordChunkSize != 0 {
@@ -353,7 +692,8 @@ func expNNMontgomeryPrecomputedParallel(x, y, m nat, table *PreTable, numRoutine
 		go table.routineExpNNMontgomery(ctx, power0, y, m, k0, wordChunkSize, pivots, outputs)
 	}
 
-	ret := power0
+	// power0 belongs to the table, so the products go to a copy
+	ret := nat(nil).set(power0)
 	temp := nat(nil).make(numWords)
 	for out := range outputs {
 		if out != nil {
@@ -370,13 +710,6 @@ func expNNMontgomeryPrecomputedParallel(x, y, m nat, table *PreTable, numRoutine
 	one[0] = 1
 	temp = temp.montgomery(ret, one, m, k0, numWords)
 	ret, temp = temp, ret
-	// final reduction
-	if ret.cmp(m) >= 0 {
-		ret = ret.sub(ret, m)
-		if ret.cmp(m) >= 0 {
-			_, ret = nat(nil).div(nil, ret, m)
-		}
-	}
-	// normalization
-	return ret.norm()
+	// final reduction and normalization
+	return finalReduce(ret, m).norm()
 }
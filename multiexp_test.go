@@ -5,6 +5,8 @@ import (
 	"io"
 	"math/big"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -445,3 +447,759 @@ func TestExpParallel(t *testing.T) {
 		})
 	}
 }
+
+func TestFourfoldExpInto(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	y4 := [4]*big.Int{x4[0], x4[1], x4[2], x4[3]}
+
+	var dst [4]*big.Int
+	var backing [4]*big.Word
+	for i := range dst {
+		// preallocate enough room for a result of the modulus size
+		dst[i] = new(big.Int).Set(n)
+		backing[i] = &dst[i].Bits()[0]
+	}
+	FourfoldExpInto(&dst, g, n, y4)
+	for i := range dst {
+		if want := new(big.Int).Exp(g, y4[i], n); want.Cmp(dst[i]) != 0 {
+			t.Errorf("Wrong result for FourfoldExpInto at index %d", i)
+		}
+		if &dst[i].Bits()[0] != backing[i] {
+			t.Errorf("FourfoldExpInto did not reuse the backing array at index %d", i)
+		}
+	}
+
+	// the fallback path must also write into dst
+	y4[2] = big.NewInt(0)
+	FourfoldExpInto(&dst, g, n, y4)
+	for i := range dst {
+		if want := new(big.Int).Exp(g, y4[i], n); want.Cmp(dst[i]) != 0 {
+			t.Errorf("Wrong result for FourfoldExpInto fallback at index %d", i)
+		}
+	}
+}
+
+func TestFourfoldExpSubset(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	y4 := [4]*big.Int{x4[0], nil, x4[2], big.NewInt(0)}
+	want := [4]bool{true, false, true, false}
+
+	result := FourfoldExpSubset(g, n, y4, want)
+	for i := range result {
+		if !want[i] {
+			if result[i] != nil {
+				t.Errorf("FourfoldExpSubset computed unwanted slot %d", i)
+			}
+			continue
+		}
+		if expected := new(big.Int).Exp(g, y4[i], n); expected.Cmp(result[i]) != 0 {
+			t.Errorf("Wrong result for FourfoldExpSubset at index %d", i)
+		}
+	}
+}
+
+func TestFourfoldExpWithProgress(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	y4 := [4]*big.Int{x4[0], x4[1], x4[2], x4[3]}
+
+	lastDone, calls := 0, 0
+	result := FourfoldExpWithProgress(g, n, y4, func(done, total int) {
+		calls++
+		if done != lastDone+1 || done > total {
+			t.Errorf("unexpected progress %d/%d after %d", done, total, lastDone)
+		}
+		lastDone = done
+	})
+	if calls == 0 {
+		t.Errorf("progress was never called")
+	}
+	for i := range result {
+		if expected := new(big.Int).Exp(g, y4[i], n); expected.Cmp(result[i]) != 0 {
+			t.Errorf("Wrong result for FourfoldExpWithProgress at index %d", i)
+		}
+	}
+	// a nil progress must be accepted
+	result = FourfoldExpWithProgress(g, n, y4, nil)
+	for i := range result {
+		if expected := new(big.Int).Exp(g, y4[i], n); expected.Cmp(result[i]) != 0 {
+			t.Errorf("Wrong result for FourfoldExpWithProgress without progress at index %d", i)
+		}
+	}
+}
+
+func TestMultiMontgomeryWindowed(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	x, m := newNat(g), newNat(n)
+	yList := []nat{newNat(x4[0]), newNat(x4[1]), nil, nat{1}, nat{0, 1}}
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	want := multiMontgomery(m, power0, power1, k0, numWords, yList)
+	for _, width := range []int{1, 3, 4, 5, 7} {
+		got := multiMontgomeryWindowed(m, power0, power1, k0, numWords, yList, width, nil)
+		for i := range got {
+			gotZ := assembleAndConvert(got[i], nil, m, k0, numWords).norm()
+			wantZ := assembleAndConvert(want[i], nil, m, k0, numWords).norm()
+			if gotZ.cmp(wantZ) != 0 {
+				t.Errorf("Wrong result for multiMontgomeryWindowed with width %d at index %d", width, i)
+			}
+		}
+	}
+}
+
+func TestMultiMontgomeryLeftToRight(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	x, m := newNat(g), newNat(n)
+	chains := fourfoldChains([4]nat{newNat(x4[0]), newNat(x4[1]), newNat(x4[2]), newNat(x4[3])})
+	yList := append([]nat{nil, nat{1}, nat{0, 1}, nat{_M}}, chains[:]...)
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	want := multiMontgomery(m, power0, power1, k0, numWords, yList)
+	for _, width := range []int{1, 2, 4, 5, 7} {
+		got := multiMontgomeryLeftToRight(m, power0, power1, k0, numWords, yList, width)
+		for i := range got {
+			gotZ := assembleAndConvert(got[i], nil, m, k0, numWords).norm()
+			wantZ := assembleAndConvert(nat(nil).set(want[i]), nil, m, k0, numWords).norm()
+			if gotZ.cmp(wantZ) != 0 {
+				t.Errorf("Wrong result for multiMontgomeryLeftToRight with width %d at index %d", width, i)
+			}
+		}
+	}
+}
+
+func TestDoubleExpEqualExponents(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	for _, y := range []*big.Int{big.NewInt(1), big.NewInt(0xffff), xList[0]} {
+		// gcw must leave both extras empty and put all of y into the common words
+		yWords := newNat(y)
+		aExtra, bExtra, common := gcw(yWords, yWords)
+		if len(aExtra.norm()) != 0 || len(bExtra.norm()) != 0 || common.cmp(yWords) != 0 {
+			t.Errorf("gcw(y, y) = %v, %v, %v, want empty, empty, y", aExtra, bExtra, common)
+		}
+
+		result := DoubleExp(g, [2]*big.Int{y, new(big.Int).Set(y)}, n)
+		want := new(big.Int).Exp(g, y, n)
+		if want.Cmp(result[0]) != 0 || want.Cmp(result[1]) != 0 {
+			t.Errorf("Wrong result for DoubleExp with equal exponents %v", y)
+		}
+	}
+}
+
+func TestExpPrecomputed(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	table := getBenchPrecomputeTable()
+	for _, y := range getDifferentBenchParameters(4) {
+		if got, want := ExpPrecomputed(g, y, n, table), new(big.Int).Exp(g, y, n); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpPrecomputed")
+		}
+	}
+	for _, y := range []*big.Int{big.NewInt(0), nil} {
+		if got := ExpPrecomputed(g, y, n, table); got.Cmp(big1) != 0 {
+			t.Errorf("ExpPrecomputed(g, %v) = %v, want 1", y, got)
+		}
+	}
+}
+
+func TestExpPrecomputedWindowed(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	table := getBenchPrecomputeTable()
+	for _, width := range []int{1, 3, 4, 7} {
+		windowed := NewWindowedPreTable(table, width)
+		for _, y := range getDifferentBenchParameters(4) {
+			if got, want := ExpPrecomputedWindowed(g, y, n, windowed), new(big.Int).Exp(g, y, n); got.Cmp(want) != 0 {
+				t.Errorf("Wrong result for ExpPrecomputedWindowed with width %d", width)
+			}
+		}
+	}
+}
+
+func TestExpWithCoResidue(t *testing.T) {
+	g, _, xList := getBenchParameters(1)
+	p, q := getPrime256(), getPrime256()
+	m := new(big.Int).Mul(p, q)
+	for _, d := range []*big.Int{p, q, big.NewInt(1000003)} {
+		full, residue := ExpWithCoResidue(g, xList[0], m, d)
+		if want := new(big.Int).Exp(g, xList[0], m); want.Cmp(full) != 0 {
+			t.Errorf("Wrong full result for ExpWithCoResidue")
+		}
+		if want := new(big.Int).Mod(full, d); want.Cmp(residue) != 0 {
+			t.Errorf("Wrong residue for ExpWithCoResidue")
+		}
+	}
+	// d | m, so the residue is x**y mod d
+	_, residue := ExpWithCoResidue(g, xList[0], m, p)
+	if want := new(big.Int).Exp(g, xList[0], p); want.Cmp(residue) != 0 {
+		t.Errorf("ExpWithCoResidue residue differs from x**y mod d")
+	}
+	if full, residue := ExpWithCoResidue(g, nil, m, p); full.Cmp(big1) != 0 || residue.Cmp(big1) != 0 {
+		t.Errorf("ExpWithCoResidue(g, nil) = %v, %v, want 1, 1", full, residue)
+	}
+}
+
+func TestExpPartials(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	table := getBenchPrecomputeTable()
+	want := new(big.Int).Exp(g, xList[0], n)
+	for _, numParts := range []int{1, 3, 7, 1000} {
+		parts := ExpPartials(g, xList[0], n, table, numParts)
+		if len(parts) != numParts {
+			t.Errorf("ExpPartials returned %d parts, want %d", len(parts), numParts)
+		}
+		if got := CombinePartials(parts, n); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for CombinePartials with %d parts", numParts)
+		}
+	}
+	// combining in a different order gives the same result
+	parts := ExpPartials(g, xList[0], n, table, 4)
+	parts[0], parts[3] = parts[3], parts[0]
+	if got := CombinePartials(parts, n); got.Cmp(want) != 0 {
+		t.Errorf("Wrong result for CombinePartials with reordered parts")
+	}
+}
+
+func TestNewPrecomputeTableTrivialBase(t *testing.T) {
+	_, n, _ := getBenchParameters(1)
+	for _, base := range []*big.Int{big.NewInt(1), new(big.Int).Add(n, big1), new(big.Int).Add(new(big.Int).Lsh(n, 3), big1)} {
+		if table := NewPrecomputeTable(base, n, 4); table != nil {
+			t.Errorf("NewPrecomputeTable built a table for a base congruent to 1")
+		}
+	}
+	if table := NewPrecomputeTable(new(big.Int).Add(n, big.NewInt(2)), n, 4); table == nil {
+		t.Errorf("NewPrecomputeTable rejected a base congruent to 2")
+	}
+}
+
+func TestExpPortablePrecomputed(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	tableSize := numTestBits/32 + 1
+	table := NewPortablePrecomputeTable(g, n, tableSize)
+	powers := table.Powers()
+	if len(powers) != tableSize*32 {
+		t.Fatalf("Powers returned %d powers, want %d", len(powers), tableSize*32)
+	}
+	for _, i := range []int{0, 1, 31, 32, 63, 64, 65, len(powers) - 1} {
+		want := new(big.Int).Exp(g, new(big.Int).Lsh(big1, uint(i)), n)
+		if powers[i].Cmp(want) != 0 {
+			t.Errorf("Powers()[%d] != g**(2**%d) mod n", i, i)
+		}
+	}
+
+	// a table rebuilt from the powers, as on another platform, gives the same results
+	shared := PortablePreTableFromPowers(g, n, powers)
+	if shared == nil {
+		t.Fatalf("PortablePreTableFromPowers rejected the powers of Powers")
+	}
+	// powers of another base, of another modulus, or with a corrupted entry are rejected
+	corrupted := append([]*big.Int(nil), powers...)
+	corrupted[40] = new(big.Int).Add(powers[40], big1)
+	for name, tc := range map[string]struct {
+		base, modulus *big.Int
+		powers        []*big.Int
+	}{
+		"other base":    {new(big.Int).Add(g, big1), n, powers},
+		"other modulus": {g, new(big.Int).Add(n, big.NewInt(2)), powers},
+		"corrupted":     {g, n, corrupted},
+	} {
+		if PortablePreTableFromPowers(tc.base, tc.modulus, tc.powers) != nil {
+			t.Errorf("PortablePreTableFromPowers accepted the powers with %s", name)
+		}
+	}
+	for _, y := range getDifferentBenchParameters(4) {
+		want := new(big.Int).Exp(g, y, n)
+		if got := ExpPortablePrecomputed(g, y, n, table); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpPortablePrecomputed")
+		}
+		if got := ExpPortablePrecomputed(g, y, n, shared); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpPortablePrecomputed with a shared table")
+		}
+	}
+	for _, y := range []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), new(big.Int).Lsh(big1, 100)} {
+		if got, want := ExpPortablePrecomputed(g, y, n, table), new(big.Int).Exp(g, y, n); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpPortablePrecomputed(g, %v)", y)
+		}
+	}
+}
+
+func TestMergeDouble(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	a, b := [2]*big.Int{x4[0], x4[1]}, [2]*big.Int{x4[2], x4[3]}
+	merged := FourfoldExp(g, n, MergeDouble(a, b))
+	da, db := DoubleExp(g, a, n), DoubleExp(g, b, n)
+	for i, want := range []*big.Int{da[0], da[1], db[0], db[1]} {
+		if merged[i].Cmp(want) != 0 {
+			t.Errorf("Wrong result for the merged fourfold call at index %d", i)
+		}
+	}
+}
+
+func TestBatchExp(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	var ys []*big.Int
+	for len(ys) < 9 {
+		ys = append(ys, getDifferentBenchParameters(4)...)
+	}
+	for l := 0; l <= 9; l++ {
+		got := BatchExp(g, n, ys[:l])
+		if len(got) != l {
+			t.Fatalf("BatchExp returned %d results for %d exponents", len(got), l)
+		}
+		for i := range got {
+			if want := new(big.Int).Exp(g, ys[i], n); got[i].Cmp(want) != 0 {
+				t.Errorf("Wrong result for BatchExp of %d exponents at index %d", l, i)
+			}
+		}
+	}
+}
+
+// getSmallTopWordModuli returns odd moduli of 4 words whose top word has leading zero bits
+func getSmallTopWordModuli() []*big.Int {
+	var moduli []*big.Int
+	for _, top := range []int64{1, 3, 0x1ff} {
+		m := big.NewInt(top)
+		m.Lsh(m, 3*_W)
+		low, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big1, 3*_W))
+		m.Or(m, low)
+		m.SetBit(m, 0, 1)
+		moduli = append(moduli, m)
+	}
+	// the largest such modulus, R/2 - 1
+	moduli = append(moduli, new(big.Int).Sub(new(big.Int).Lsh(big1, 4*_W-1), big1))
+	return moduli
+}
+
+func TestSmallTopWordModulus(t *testing.T) {
+	getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	y4 := [4]*big.Int{x4[0], x4[1], x4[2], x4[3]}
+	for _, m := range getSmallTopWordModuli() {
+		// a base above m takes the reduction path of montgomeryPowers
+		for _, g := range []*big.Int{new(big.Int).Sub(m, big.NewInt(5)), new(big.Int).Lsh(m, 5)} {
+			g.Add(g, big.NewInt(2))
+			want := make([]*big.Int, 4)
+			for i := range want {
+				want[i] = new(big.Int).Exp(g, y4[i], m)
+			}
+			check := func(name string, got []*big.Int) {
+				for i := range got {
+					if got[i].Cmp(want[i]) != 0 {
+						t.Errorf("Wrong result for %s with modulus %x at index %d", name, m, i)
+					}
+				}
+			}
+			fourfold := FourfoldExp(g, m, y4)
+			check("FourfoldExp", fourfold[:])
+			double := DoubleExp(g, [2]*big.Int{y4[0], y4[1]}, m)
+			check("DoubleExp", double[:])
+
+			table := NewPrecomputeTable(g, m, (numTestBits/_W)+1)
+			precomputed := FourfoldExpPrecomputed(g, m, y4, table)
+			check("FourfoldExpPrecomputed", precomputed[:])
+			check("ExpParallel", []*big.Int{ExpParallel(g, y4[0], m, table, 4, 0)})
+			check("ExpWithMontContext", []*big.Int{ExpWithMontContext(g, y4[0], NewMontContext(m))})
+		}
+	}
+}
+
+func TestExponentProfile(t *testing.T) {
+	for _, tc := range []struct {
+		y                      *big.Int
+		bitLen, setBits, words int
+	}{
+		{nil, 0, 0, 0},
+		{big.NewInt(0), 0, 0, 0},
+		{big.NewInt(1), 1, 1, 1},
+		{big.NewInt(-0xff), 8, 8, 1},
+		{new(big.Int).Lsh(big.NewInt(5), _W), _W + 3, 2, 2},
+	} {
+		bitLen, setBits, words := ExponentProfile(tc.y)
+		if bitLen != tc.bitLen || setBits != tc.setBits || words != tc.words {
+			t.Errorf("ExponentProfile(%v) = %d, %d, %d, want %d, %d, %d",
+				tc.y, bitLen, setBits, words, tc.bitLen, tc.setBits, tc.words)
+		}
+	}
+}
+
+func TestParallelSingleCore(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	g, n, _ := getBenchParameters(1)
+	table := getBenchPrecomputeTable()
+	x4 := getDifferentBenchParameters(4)
+	y4 := [4]*big.Int{x4[0], x4[1], x4[2], x4[3]}
+	result := FourfoldExpPrecomputedParallel(g, n, y4, table)
+	for i := range result {
+		want := new(big.Int).Exp(g, y4[i], n)
+		if result[i].Cmp(want) != 0 {
+			t.Errorf("Wrong result for FourfoldExpPrecomputedParallel with GOMAXPROCS=1 at index %d", i)
+		}
+		for _, numRoutine := range []int{1, 4} {
+			if got := ExpParallel(g, y4[i], n, table, numRoutine, 0); got.Cmp(want) != 0 {
+				t.Errorf("Wrong result for ExpParallel with GOMAXPROCS=1 and %d routines", numRoutine)
+			}
+		}
+	}
+}
+
+func TestPreTableMontgomeryConstants(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	table := getBenchPrecomputeTable()
+	power0, _, k0, numWords := montgomerySetup(newNat(g), newNat(n))
+	if table.power0.cmp(power0) != 0 || table.k0 != k0 || table.numWords != numWords {
+		t.Fatalf("the table constants differ from montgomerySetup")
+	}
+	// the cached power0 is shared by the calls and must stay untouched
+	x4 := getDifferentBenchParameters(4)
+	y4 := [4]*big.Int{x4[0], x4[1], x4[2], x4[3]}
+	for i := 0; i < 2; i++ {
+		result := FourfoldExpPrecomputedParallel(g, n, y4, table)
+		for j := range result {
+			if want := new(big.Int).Exp(g, y4[j], n); result[j].Cmp(want) != 0 {
+				t.Errorf("Wrong result for FourfoldExpPrecomputedParallel at index %d", j)
+			}
+			if got, want := ExpParallel(g, y4[j], n, table, 4, 2), new(big.Int).Exp(g, y4[j], n); got.Cmp(want) != 0 {
+				t.Errorf("Wrong result for ExpParallel at index %d", j)
+			}
+		}
+	}
+	if table.power0.cmp(power0) != 0 {
+		t.Errorf("the shared power0 of the table was modified")
+	}
+	// a table without the cached constants computes them
+	bare := &PreTable{Base: table.Base, Modulus: table.Modulus, TableSize: table.TableSize, table: table.table}
+	if got, want := ExpPrecomputed(g, x4[0], n, bare), new(big.Int).Exp(g, x4[0], n); got.Cmp(want) != 0 {
+		t.Errorf("Wrong result for ExpPrecomputed with a table without constants")
+	}
+}
+
+func TestFourfoldExpChecked(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	y4 := [4]*big.Int{x4[0], x4[1], nil, x4[3]}
+	result, err := FourfoldExpChecked(g, n, y4)
+	if err != nil {
+		t.Fatalf("FourfoldExpChecked: %v", err)
+	}
+	if err := verifyFourfold(g, n, y4, result); err != nil {
+		t.Fatalf("verifyFourfold rejected correct results: %v", err)
+	}
+	// a divergent slot is named in the error
+	result[2] = big.NewInt(2)
+	if err := verifyFourfold(g, n, y4, result); err == nil || !strings.Contains(err.Error(), "result 2") {
+		t.Errorf("verifyFourfold(corrupted slot 2) = %v, want an error naming slot 2", err)
+	}
+}
+
+func TestFourfoldBitDisjoint(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	table := getBenchPrecomputeTable()
+	// each exponent keeps its own range of bits of a random value, so every shared chain is zero
+	bits := xList[0].BitLen()
+	var y4 [4]*big.Int
+	for i := range y4 {
+		lo, hi := i*bits/4, (i+1)*bits/4
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big1, uint(hi)), new(big.Int).Lsh(big1, uint(lo)))
+		y4[i] = new(big.Int).And(xList[0], mask)
+	}
+	// and interleaved bits within the same words, of different lengths
+	var y4Interleaved [4]*big.Int
+	for i := range y4Interleaved {
+		y4Interleaved[i] = new(big.Int)
+		for b := i; b < 64*(i+1)+3; b += 4 {
+			y4Interleaved[i].SetBit(y4Interleaved[i], b, 1)
+		}
+	}
+	for _, y := range [][4]*big.Int{y4, y4Interleaved} {
+		chains := fourfoldChains([4]nat{newNat(y[0]), newNat(y[1]), newNat(y[2]), newNat(y[3])})
+		for c := 4; c < len(chains); c++ {
+			if len(chains[c].norm()) != 0 {
+				t.Fatalf("chain %d %v is not zero for bit-disjoint exponents", c, fourfoldChainSets[c])
+			}
+		}
+		results := map[string][4]*big.Int{
+			"FourfoldExp":                    FourfoldExp(g, n, y),
+			"FourfoldExpPrecomputed":         FourfoldExpPrecomputed(g, n, y, table),
+			"FourfoldExpPrecomputedParallel": FourfoldExpPrecomputedParallel(g, n, y, table),
+			"FourfoldExpSubset":              FourfoldExpSubset(g, n, y, [4]bool{true, true, true, true}),
+		}
+		for name, result := range results {
+			for i := range result {
+				if want := new(big.Int).Exp(g, y[i], n); result[i].Cmp(want) != 0 {
+					t.Errorf("Wrong result for %s with bit-disjoint exponents at index %d", name, i)
+				}
+			}
+		}
+	}
+}
+
+func TestK0Inverse(t *testing.T) {
+	words := []Word{1, 3, 5, _M, _M - 2, 1<<(_W-1) | 1, _M / 3, _M/3 + 2, 0x10001}
+	for i := 0; i < 1000; i++ {
+		w, err := rand.Int(rand.Reader, new(big.Int).Lsh(big1, _W))
+		if err != nil {
+			t.Fatal(err)
+		}
+		words = append(words, Word(w.Uint64())|1)
+	}
+	for _, w := range words {
+		// only the low word determines k0, the high one makes sure it is not assumed to be m
+		for _, m := range []nat{{w}, {w, 1}} {
+			k0, _, _ := montgomeryConstants(m)
+			if m[0]*-k0 != 1 {
+				t.Errorf("k0 = %#x for m[0] = %#x, m[0]*-k0 = %#x, want 1", k0, m[0], m[0]*-k0)
+			}
+		}
+	}
+}
+
+func TestFourfoldExpRepeated(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	for _, tc := range []struct {
+		name        string
+		y4          [4]*big.Int
+		conversions uint64
+	}{
+		{"all identical", [4]*big.Int{x4[0], x4[0], new(big.Int).Set(x4[0]), x4[0]}, 1},
+		{"two distinct", [4]*big.Int{x4[0], x4[1], x4[1], x4[0]}, 2},
+		{"three distinct", [4]*big.Int{x4[0], x4[1], x4[2], new(big.Int).Set(x4[1])}, 3},
+		{"four distinct", [4]*big.Int{x4[0], x4[1], x4[2], x4[3]}, 4},
+	} {
+		// each distinct exponent is converted out of the Montgomery form once
+		before := ReductionStats()
+		result := FourfoldExp(g, n, tc.y4)
+		if got := ReductionStats().Conversions - before.Conversions; got != tc.conversions {
+			t.Errorf("%s: %d conversions, want %d", tc.name, got, tc.conversions)
+		}
+		for i := range result {
+			if want := new(big.Int).Exp(g, tc.y4[i], n); result[i].Cmp(want) != 0 {
+				t.Errorf("%s: wrong result at index %d", tc.name, i)
+			}
+			for j := 0; j < i; j++ {
+				if result[i] == result[j] {
+					t.Errorf("%s: the slots %d and %d share their result", tc.name, j, i)
+				}
+			}
+		}
+	}
+}
+
+func TestPreTablePowerAt(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	table := getBenchPrecomputeTable()
+	for _, i := range []int{0, 1, _W - 1, _W, 3*_W + 5, table.TableSize*_W - 1} {
+		want := new(big.Int).Exp(g, new(big.Int).Lsh(big1, uint(i)), n)
+		if got := table.PowerAt(i); got == nil || got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for PowerAt(%d)", i)
+		}
+	}
+	for _, i := range []int{-1, table.TableSize * _W} {
+		if got := table.PowerAt(i); got != nil {
+			t.Errorf("PowerAt(%d) = %v, want nil", i, got)
+		}
+	}
+}
+
+func TestExpTwoLevelPrecomputed(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	ys := append(getDifferentBenchParameters(4), big.NewInt(1), big.NewInt(0), new(big.Int).Lsh(big1, numTestBits-1))
+	for _, levelBits := range []int{1, 3, 16, 64, 100} {
+		table := NewTwoLevelPreTable(g, n, numTestBits, levelBits)
+		if table.LevelBits() != levelBits {
+			t.Errorf("LevelBits = %d, want %d", table.LevelBits(), levelBits)
+		}
+		for _, y := range ys {
+			if got, want := ExpTwoLevelPrecomputed(g, y, n, table), new(big.Int).Exp(g, y, n); got.Cmp(want) != 0 {
+				t.Errorf("Wrong result for ExpTwoLevelPrecomputed with %d level bits", levelBits)
+			}
+		}
+	}
+	if NewTwoLevelPreTable(g, n, numTestBits, 0) != nil {
+		t.Errorf("NewTwoLevelPreTable accepted no level bits")
+	}
+}
+
+func TestMultiMontgomeryTopBit(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x, m := newNat(g), newNat(n)
+	// unnormalized exponents, whose words above the highest set bit are zero
+	yList := []nat{{5, 0, 0}, {1 << 3, 0}, {0, 1, 0, 0}, {0}}
+	power0, power1, k0, numWords := montgomerySetup(x, m)
+	for _, width := range []int{0, 3, 4} {
+		var z []nat
+		var lastDone, lastTotal int
+		progress := func(done, total int) { lastDone, lastTotal = done, total }
+		if width == 0 {
+			z = multiMontgomeryWithProgress(m, power0, power1, k0, numWords, yList, progress)
+		} else {
+			z = multiMontgomeryWindowed(m, power0, power1, k0, numWords, yList, width, progress)
+		}
+		for i, y := range yList {
+			zWords := assembleAndConvert(z[i], nil, m, k0, numWords).norm()
+			want := new(big.Int).Exp(g, new(big.Int).SetBits(y.norm().intBits()), n)
+			if new(big.Int).SetBits(zWords.intBits()).Cmp(want) != 0 {
+				t.Errorf("Wrong result with window width %d at index %d", width, i)
+			}
+		}
+		if lastDone != 2 || lastTotal != 2 {
+			t.Errorf("last progress %d/%d with window width %d, want 2/2", lastDone, lastTotal, width)
+		}
+	}
+}
+
+func TestFallbackBoundary(t *testing.T) {
+	_, n, _ := getBenchParameters(1)
+	y := big.NewInt(65537)
+	for _, tc := range []struct {
+		name string
+		x, m *big.Int
+		ys   [4]*big.Int
+	}{
+		{"x = 1", big.NewInt(1), n, [4]*big.Int{y, y, y, y}},
+		{"x = 0", big.NewInt(0), n, [4]*big.Int{y, y, big.NewInt(0), y}},
+		{"x < 0", big.NewInt(-7), n, [4]*big.Int{y, big.NewInt(3), y, y}},
+		{"m = nil", big.NewInt(7), nil, [4]*big.Int{big.NewInt(5), big.NewInt(9), big.NewInt(0), big.NewInt(2)}},
+		{"m = 0", big.NewInt(7), big.NewInt(0), [4]*big.Int{big.NewInt(5), big.NewInt(9), big.NewInt(-1), big.NewInt(2)}},
+		{"m < 0", big.NewInt(7), new(big.Int).Neg(n), [4]*big.Int{y, y, y, y}},
+		{"m even", big.NewInt(7), big.NewInt(1 << 20), [4]*big.Int{y, y, y, y}},
+		{"m = 1", big.NewInt(7), big.NewInt(1), [4]*big.Int{y, y, y, y}},
+		{"y = 0", big.NewInt(7), n, [4]*big.Int{big.NewInt(0), y, y, y}},
+		{"y < 0", big.NewInt(7), n, [4]*big.Int{y, big.NewInt(-5), y, y}},
+		{"y < 0 not invertible", big.NewInt(5), big.NewInt(15), [4]*big.Int{y, y, y, big.NewInt(-1)}},
+		{"y = nil", big.NewInt(7), n, [4]*big.Int{y, y, nil, y}},
+		{"fast path", big.NewInt(7), n, [4]*big.Int{y, big.NewInt(3), y, big.NewInt(1)}},
+	} {
+		var want [4]*big.Int
+		for i := range tc.ys {
+			want[i] = new(big.Int).Exp(tc.x, exponentOrZero(tc.ys[i]), tc.m)
+		}
+		check := func(fn string, i int, got *big.Int) {
+			if (got == nil) != (want[i] == nil) || got != nil && got.Cmp(want[i]) != 0 {
+				t.Errorf("%s: %s = %v at index %d, want %v", tc.name, fn, got, i, want[i])
+			}
+		}
+
+		double := DoubleExp(tc.x, [2]*big.Int{tc.ys[0], tc.ys[1]}, tc.m)
+		double2 := DoubleExp(tc.x, [2]*big.Int{tc.ys[2], tc.ys[3]}, tc.m)
+		for i, got := range append(double[:], double2[:]...) {
+			check("DoubleExp", i, got)
+		}
+		fourfold := FourfoldExp(tc.x, tc.m, tc.ys)
+		for i, got := range fourfold {
+			check("FourfoldExp", i, got)
+		}
+		table := &PreTable{Base: tc.x, Modulus: tc.m}
+		if tc.m != nil {
+			table = NewPrecomputeTable(tc.x, tc.m, 1)
+			if table == nil {
+				// only the fast path reads the table entries
+				table = &PreTable{Base: tc.x, Modulus: tc.m}
+			}
+		}
+		for i := range tc.ys {
+			func() {
+				defer func() {
+					// a nil modulus never matches a table
+					if r := recover(); (r != nil) != (tc.m == nil) {
+						t.Errorf("%s: ExpParallel panic = %v, want a panic only for a nil modulus", tc.name, r)
+					}
+				}()
+				check("ExpParallel", i, ExpParallel(tc.x, tc.ys[i], tc.m, table, 2, 0))
+			}()
+		}
+	}
+}
+
+func TestFallbackBoundaryTableMismatch(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	table := NewPrecomputeTable(g, n, 1)
+	for _, tc := range []struct {
+		name  string
+		x, m  *big.Int
+		table *PreTable
+	}{
+		{"nil table", g, n, nil},
+		{"other base", big.NewInt(7), n, table},
+		{"other modulus", g, new(big.Int).Add(n, big.NewInt(2)), table},
+		{"nil modulus", g, nil, table},
+		// the table is checked before the fallback
+		{"x = 1", big1, n, table},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: ExpParallel did not panic", tc.name)
+				}
+			}()
+			ExpParallel(tc.x, big.NewInt(5), tc.m, tc.table, 2, 0)
+		}()
+	}
+}
+
+func TestTableMismatchNilModulus(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	windowed := NewWindowedPreTable(getBenchPrecomputeTable(), 4)
+	portable := NewPortablePrecomputeTable(g, n, 2)
+	twoLevel := NewTwoLevelPreTable(g, n, 64, 8)
+	for name, exp := range map[string]func(){
+		"ExpPrecomputedWindowed": func() { ExpPrecomputedWindowed(g, big.NewInt(5), nil, windowed) },
+		"ExpPortablePrecomputed": func() { ExpPortablePrecomputed(g, big.NewInt(5), nil, portable) },
+		"ExpTwoLevelPrecomputed": func() { ExpTwoLevelPrecomputed(g, big.NewInt(5), nil, twoLevel) },
+	} {
+		func() {
+			defer func() {
+				if msg, _ := recover().(string); msg != "precompute table not match: invalid modulus" {
+					t.Errorf("%s with a nil modulus panicked with %q", name, msg)
+				}
+			}()
+			exp()
+		}()
+	}
+}
+
+func TestReductionStats(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	before := ReductionStats()
+	ys := getDifferentBenchParameters(4)
+	FourfoldExp(g, n, [4]*big.Int{ys[0], ys[1], ys[2], ys[3]})
+	DoubleExp(g, [2]*big.Int{ys[0], ys[1]}, n)
+	for _, m := range getSmallTopWordModuli() {
+		DoubleExp(g, [2]*big.Int{ys[2], ys[3]}, m)
+	}
+	after := ReductionStats()
+	if got := after.Conversions - before.Conversions; got < 6 {
+		t.Errorf("%d conversions counted, want at least 6", got)
+	}
+	if after.Subtractions < before.Subtractions || after.Divisions < before.Divisions {
+		t.Errorf("the reduction counts decreased")
+	}
+	// one subtraction always suffices, see finalReduce
+	if after.Divisions != before.Divisions {
+		t.Errorf("%d final reductions needed a division", after.Divisions-before.Divisions)
+	}
+}
+
+func TestDoubleExpState(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	state := NewDoubleExpState(g, n)
+	// the exponents grow by their higher bits
+	for _, bits := range []uint{0, 1, 70, 1000, 1000, 5000, numTestBits} {
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big1, bits), big1)
+		y2 := [2]*big.Int{new(big.Int).And(x4[0], mask), new(big.Int).And(x4[1], mask)}
+		got := state.Extend(y2)
+		for i := range got {
+			if want := new(big.Int).Exp(g, y2[i], n); got[i].Cmp(want) != 0 {
+				t.Errorf("Wrong result for DoubleExpState.Extend to %d bits at index %d", bits, i)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Extend accepted exponents that change the processed bits")
+		}
+	}()
+	state.Extend([2]*big.Int{x4[0], new(big.Int).Add(x4[1], big1)})
+}
@@ -15,6 +15,7 @@ package multiexp
 
 import (
 	"math/big"
+	"math/bits"
 	"sync"
 )
 
@@ -61,6 +62,19 @@ func (z nat) intBits() []big.Word {
 	return zBits
 }
 
+// setIntBits sets dst to z, reusing the backing array of dst when it is large enough, and returns dst.
+func (z nat) setIntBits(dst *big.Int) *big.Int {
+	zBits := dst.Bits()
+	if cap(zBits) < len(z) {
+		zBits = make([]big.Word, len(z))
+	}
+	zBits = zBits[:len(z)]
+	for i, d := range z {
+		zBits[i] = big.Word(d)
+	}
+	return dst.SetBits(zBits)
+}
+
 func (z nat) clear() {
 	for i := range z {
 		z[i] = 0
@@ -75,6 +89,15 @@ func (z nat) norm() nat {
 	return z[0:i]
 }
 
+// bitLen returns the length of the absolute value of x in bits; x need not be normalized.
+func (x nat) bitLen() int {
+	x = x.norm()
+	if i := len(x) - 1; i >= 0 {
+		return i*_W + _W - int(nlz(x[i]))
+	}
+	return 0
+}
+
 func (z nat) make(n int) nat {
 	if n <= cap(z) {
 		return z[:n] // reuse z
@@ -121,7 +144,7 @@ func (z nat) sub(x, y nat) nat {
 	// m > 0
 
 	z = z.make(m)
-	c := subVV(z[0:n], x, y)
+	c := subVV(z[0:n], x[0:n], y)
 	if m > n {
 		c = subVW(z[n:], x[n:], c)
 	}
@@ -228,15 +251,15 @@ func (z nat) montgomery(x, y, m nat, k Word, n int) nat {
 // Fast version of z[0:n+n>>1].add(z[0:n+n>>1], x[0:n]) w/o bounds checks.
 // Factored out for readability - do not use outside karatsuba.
 func karatsubaAdd(z, x nat, n int) {
-	if c := addVV(z[0:n], z, x); c != 0 {
-		addVW(z[n:n+n>>1], z[n:], c)
+	if c := addVV(z[0:n], z[0:n], x[0:n]); c != 0 {
+		addVW(z[n:n+n>>1], z[n:n+n>>1], c)
 	}
 }
 
 // Like karatsubaAdd, but does subtract.
 func karatsubaSub(z, x nat, n int) {
-	if c := subVV(z[0:n], z, x); c != 0 {
-		subVW(z[n:n+n>>1], z[n:], c)
+	if c := subVV(z[0:n], z[0:n], x[0:n]); c != 0 {
+		subVW(z[n:n+n>>1], z[n:n+n>>1], c)
 	}
 }
 
@@ -359,7 +382,7 @@ func alias(x, y nat) bool {
 // slice, and we don't need to normalize z after each addition)
 func addAt(z, x nat, i int) {
 	if n := len(x); n > 0 {
-		if c := addVV(z[i:i+n], z[i:], x); c != 0 {
+		if c := addVV(z[i:i+n], z[i:i+n], x); c != 0 {
 			j := i + n
 			if j < len(z) {
 				addVW(z[j:], z[j:], c)
@@ -375,6 +398,19 @@ func max(x, y int) int {
 	return y
 }
 
+// maxInt is the largest value of type int.
+const maxInt = int(^uint(0) >> 1)
+
+// karatsubaSpace returns max(6*k, m+n), the length of z needed by mul to multiply
+// an m-word x by an n-word y with Karatsuba length k.
+// ok is false if the length overflows int.
+func karatsubaSpace(k, m, n int) (space int, ok bool) {
+	if k > maxInt/6 || m > maxInt-n {
+		return 0, false
+	}
+	return max(6*k, m+n), true
+}
+
 // karatsubaLen computes an approximation to the maximum k <= n such that
 // k = p<<i for a number p <= threshold and an i >= 0. Thus, the
 // result is the largest number that can be divided repeatedly by 2 before
@@ -407,6 +443,12 @@ func (z nat) mul(x, y nat) nat {
 		z = nil // z is an alias for x or y - cannot reuse
 	}
 
+	// the result length m+n must be representable, or make would
+	// panic with a misleading length (or allocate the wrong size)
+	if m > maxInt-n {
+		panic("multiexp: product length overflows int")
+	}
+
 	// use basic multiplication if the numbers are small
 	if n < karatsubaThreshold {
 		z = z.make(m + n)
@@ -424,10 +466,19 @@ func (z nat) mul(x, y nat) nat {
 	k := karatsubaLen(n, karatsubaThreshold)
 	// k <= n
 
+	space, ok := karatsubaSpace(k, m, n)
+	if !ok {
+		// the Karatsuba temporary storage cannot be addressed, fall back
+		// to basic multiplication which only needs room for the result
+		z = z.make(m + n)
+		basicMul(z, x, y)
+		return z.norm()
+	}
+
 	// multiply x0 and y0 via Karatsuba
-	x0 := x[0:k]              // x0 is not normalized
-	y0 := y[0:k]              // y0 is not normalized
-	z = z.make(max(6*k, m+n)) // enough space for karatsuba of x0*y0 and full result of x*y
+	x0 := x[0:k]      // x0 is not normalized
+	y0 := y[0:k]      // y0 is not normalized
+	z = z.make(space) // enough space for karatsuba of x0*y0 and full result of x*y
 	karatsuba(z, x0, y0)
 	z = z[0 : m+n]  // z has final length but may be incomplete
 	z[2*k:].clear() // upper portion of z is garbage (and 2*k <= m+n since k <= n <= m)
@@ -526,3 +577,39 @@ func (z nat) shl(x nat, s uint) nat {
 
 	return z.norm()
 }
+
+// z = x >> s
+func (z nat) shr(x nat, s uint) nat {
+	if s == 0 {
+		if same(z, x) {
+			return z
+		}
+		if !alias(z, x) {
+			return z.set(x)
+		}
+	}
+
+	m := len(x)
+	n := m - int(s/_W)
+	if n <= 0 {
+		return z[:0]
+	}
+	// n > 0
+
+	z = z.make(n)
+	shrVU(z, x[m-n:], s%_W)
+
+	return z.norm()
+}
+
+// trailingZeroBits returns the number of consecutive least significant zero
+// bits of x.
+func (x nat) trailingZeroBits() uint {
+	for i, w := range x {
+		if w != 0 {
+			return uint(i)*_W + uint(bits.TrailingZeros(uint(w)))
+		}
+	}
+	// x == 0
+	return 0
+}
@@ -0,0 +1,23 @@
+package multiexp
+
+import "testing"
+
+func TestKaratsubaSpace(t *testing.T) {
+	tests := []struct {
+		k, m, n int
+		space   int
+		ok      bool
+	}{
+		{k: 40, m: 40, n: 40, space: 240, ok: true},
+		{k: 64, m: 1000, n: 100, space: 1100, ok: true},
+		{k: maxInt / 6, m: maxInt / 6, n: maxInt / 6, space: maxInt / 6 * 6, ok: true},
+		{k: maxInt/6 + 1, m: maxInt/6 + 1, n: maxInt/6 + 1, ok: false},
+		{k: 64, m: maxInt - 10, n: 64, ok: false},
+	}
+	for _, tt := range tests {
+		space, ok := karatsubaSpace(tt.k, tt.m, tt.n)
+		if ok != tt.ok || (ok && space != tt.space) {
+			t.Errorf("karatsubaSpace(%d, %d, %d) = %d, %v, want %d, %v", tt.k, tt.m, tt.n, space, ok, tt.space, tt.ok)
+		}
+	}
+}
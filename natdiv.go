@@ -5,6 +5,7 @@
 package multiexp
 
 import (
+	"fmt"
 	"math/bits"
 )
 
@@ -175,9 +176,9 @@ func (q nat) divBasic(u, v nat) {
 		// Subtract q̂·v from the current section of u.
 		// If it underflows, q̂·v > u, which we fix up
 		// by decrementing q̂ and adding v back.
-		c := subVV(u[j:j+qhl], u[j:], qhatv)
+		c := subVV(u[j:j+qhl], u[j:j+qhl], qhatv[0:qhl])
 		if c != 0 {
-			c := addVV(u[j:j+n], u[j:], v)
+			c := addVV(u[j:j+n], u[j:j+n], v)
 			// If n == qhl, the carry from subVV and the carry from addVV
 			// cancel out and don't affect u[j+n].
 			if n < qhl {
@@ -206,7 +207,26 @@ func greaterThan(x1, x2, y1, y2 Word) bool {
 
 // divRecursiveThreshold is the number of divisor digits
 // at which point divRecursive is faster than divBasic.
-const divRecursiveThreshold = 100
+// It defaults to the calibration of math/big, see SetDivRecursiveThreshold.
+var divRecursiveThreshold = 100
+
+// minDivRecursiveThreshold is the smallest divisor length divRecursiveStep can split:
+// below 4 digits the wide digits get too short for the 3-by-2 wide-digit steps.
+const minDivRecursiveThreshold = 4
+
+// SetDivRecursiveThreshold sets the number of divisor words at which the division switches
+// from the basic long division to the recursive one, and returns the previous value.
+// n is clamped to the smallest length the recursive division supports.
+// BenchmarkDivRecursiveThreshold measures the crossover on the current machine.
+// SetDivRecursiveThreshold must not be called concurrently with any computation of this package.
+func SetDivRecursiveThreshold(n int) int {
+	if n < minDivRecursiveThreshold {
+		n = minDivRecursiveThreshold
+	}
+	prev := divRecursiveThreshold
+	divRecursiveThreshold = n
+	return prev
+}
 
 // divRecursive implements recursive division as described above.
 // It overwrites z with ⌊u/v⌋ and overwrites u with the remainder r.
@@ -335,7 +355,7 @@ func (z nat) divRecursiveStep(u, v nat, depth int, tmp *nat, temps []*nat) {
 			addAt(uu[s:], v[s:], 0)
 		}
 		if qhatv.cmp(uu.norm()) > 0 {
-			panic("impossible")
+			panicImpossibleDiv("q̂·v > u after refining the wide digit guess", uu, v, depth)
 		}
 		c := subVV(uu[:len(qhatv)], uu[:len(qhatv)], qhatv)
 		if c > 0 {
@@ -369,16 +389,37 @@ func (z nat) divRecursiveStep(u, v nat, depth int, tmp *nat, temps []*nat) {
 		}
 	}
 	if qhatv.cmp(u.norm()) > 0 {
-		panic("impossible")
+		panicImpossibleDiv("q̂·v > u after refining the final guess", u, v, depth)
 	}
 	c := subVV(u[0:len(qhatv)], u[0:len(qhatv)], qhatv)
 	if c > 0 {
 		c = subVW(u[len(qhatv):], u[len(qhatv):], c)
 	}
 	if c > 0 {
-		panic("impossible")
+		panicImpossibleDiv("borrow out of the final remainder", u, v, depth)
 	}
 
 	// Done!
 	addAt(z, qhat.norm(), 0)
 }
+
+// panicImpossibleDiv panics for a state of divRecursiveStep that cannot happen for valid inputs.
+// The message carries the operand lengths and the top words of u and v to make a report debuggable.
+func panicImpossibleDiv(reason string, u, v nat, depth int) {
+	panic(fmt.Sprintf("multiexp: impossible division state: %s (depth %d, len(u) = %d, len(v) = %d, "+
+		"top words of u = %#x, top words of v = %#x)", reason, depth, len(u), len(v), topWords(u), topWords(v)))
+}
+
+// topWords returns up to the 3 most significant words of the normalized x, most significant first.
+func topWords(x nat) []Word {
+	x = x.norm()
+	n := len(x)
+	if n > 3 {
+		n = 3
+	}
+	top := make([]Word, n)
+	for i := range top {
+		top[i] = x[len(x)-1-i]
+	}
+	return top
+}
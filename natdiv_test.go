@@ -0,0 +1,129 @@
+package multiexp
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// divCheck divides u by v with nat.div and compares the result against big.Int.
+func divCheck(t *testing.T, u, v nat) {
+	uInt := new(big.Int).SetBits(u.intBits())
+	vInt := new(big.Int).SetBits(v.intBits())
+	q, r := nat(nil).div(nil, u, v)
+	qInt := new(big.Int).SetBits(q.norm().intBits())
+	rInt := new(big.Int).SetBits(r.norm().intBits())
+
+	// q*v + r == u
+	prod := new(big.Int).Mul(qInt, vInt)
+	if prod.Add(prod, rInt).Cmp(uInt) != 0 {
+		t.Fatalf("q*v + r != u, len(u) = %d, len(v) = %d", len(u), len(v))
+	}
+	// r < v
+	if rInt.Cmp(vInt) >= 0 {
+		t.Fatalf("r >= v, len(u) = %d, len(v) = %d", len(u), len(v))
+	}
+	wantQ, wantR := new(big.Int).QuoRem(uInt, vInt, new(big.Int))
+	if qInt.Cmp(wantQ) != 0 || rInt.Cmp(wantR) != 0 {
+		t.Fatalf("quotient or remainder differ from big.Int, len(u) = %d, len(v) = %d", len(u), len(v))
+	}
+}
+
+// randNat returns a normalized nat of exactly n words. If sparse is set,
+// most words are zero or all ones, which stresses the guess refinement.
+func randNat(r *rand.Rand, n int, sparse bool) nat {
+	z := make(nat, n)
+	for i := range z {
+		z[i] = Word(r.Uint64())
+		if sparse {
+			switch r.Intn(3) {
+			case 0:
+				z[i] = 0
+			case 1:
+				z[i] = _M
+			}
+		}
+	}
+	for z[n-1] == 0 {
+		z[n-1] = Word(r.Uint64())
+	}
+	return z
+}
+
+func TestDivRecursiveThreshold(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := divRecursiveThreshold - 3; n <= divRecursiveThreshold+3; n++ {
+		for _, m := range []int{n, n + 1, n + n/2, 2 * n, 3*n + 1} {
+			for _, sparse := range []bool{false, true} {
+				divCheck(t, randNat(r, m, sparse), randNat(r, n, sparse))
+			}
+		}
+	}
+}
+
+func TestSetDivRecursiveThreshold(t *testing.T) {
+	defer SetDivRecursiveThreshold(SetDivRecursiveThreshold(1))
+	if divRecursiveThreshold != minDivRecursiveThreshold {
+		t.Fatalf("SetDivRecursiveThreshold(1) set %d, want %d", divRecursiveThreshold, minDivRecursiveThreshold)
+	}
+	// every division beyond 3 words is now recursive
+	r := rand.New(rand.NewSource(1))
+	for n := 1; n <= 130; n += 3 {
+		for _, m := range []int{n, n + 1, 2 * n, 3*n + 1} {
+			for _, sparse := range []bool{false, true} {
+				divCheck(t, randNat(r, m, sparse), randNat(r, n, sparse))
+			}
+		}
+	}
+}
+
+// BenchmarkDivRecursiveThreshold compares the basic division of 2n by n words with a recursive
+// division whose halves are divided by the basic one. The smallest n where recursive is faster
+// is the value to pass to SetDivRecursiveThreshold.
+func BenchmarkDivRecursiveThreshold(b *testing.B) {
+	defer SetDivRecursiveThreshold(SetDivRecursiveThreshold(divRecursiveThreshold))
+	r := rand.New(rand.NewSource(1))
+	for n := 20; n <= 200; n += 20 {
+		u, v := randNat(r, 2*n, false), randNat(r, n, false)
+		for _, div := range []struct {
+			name      string
+			threshold int
+		}{{"basic", maxInt}, {"recursive", n}} {
+			b.Run(fmt.Sprintf("n=%d/%s", n, div.name), func(b *testing.B) {
+				SetDivRecursiveThreshold(div.threshold)
+				var q, rem nat
+				for i := 0; i < b.N; i++ {
+					q, rem = q.div(rem, u, v)
+				}
+			})
+		}
+	}
+}
+
+func FuzzDiv(f *testing.F) {
+	f.Add(uint16(2), uint16(2), int64(0), false)
+	f.Add(uint16(300), uint16(99), int64(1), false)
+	f.Add(uint16(300), uint16(100), int64(2), true)
+	f.Add(uint16(250), uint16(101), int64(3), true)
+	f.Add(uint16(201), uint16(200), int64(4), false)
+	f.Fuzz(func(t *testing.T, uLen, vLen uint16, seed int64, sparse bool) {
+		// restrict the lengths to 2..300 words
+		m, n := 2+int(uLen)%299, 2+int(vLen)%299
+		r := rand.New(rand.NewSource(seed))
+		divCheck(t, randNat(r, m, sparse), randNat(r, n, sparse))
+	})
+}
+
+func TestPanicImpossibleDiv(t *testing.T) {
+	defer func() {
+		msg, _ := recover().(string)
+		for _, want := range []string{"borrow", "depth 2", "len(u) = 6", "len(v) = 2", "[0x5 0x4 0x3]", "[0x7 0x6]"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("panic message %q does not contain %q", msg, want)
+			}
+		}
+	}()
+	panicImpossibleDiv("borrow", nat{1, 2, 3, 4, 5, 0}, nat{6, 7}, 2)
+}
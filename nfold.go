@@ -0,0 +1,218 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// NfoldExp computes x**ys[i] mod |m| for all the exponents, sharing the squarings of x among them.
+// A nil exponent is treated as 0, so its result is 1 (0 if |m| == 1).
+// An empty ys gives an empty, non-nil result slice.
+//
+// NfoldExp is not a cryptographically constant-time operation.
+func NfoldExp(x, m *big.Int, ys []*big.Int) []*big.Int {
+	ret := make([]*big.Int, len(ys))
+	// make sure x > 1, m is not nil, m > 0 and m is odd, otherwise, use default Exp function
+	useDefault := x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1
+	var yWords []nat
+	var indices []int
+	for i, y := range ys {
+		if y == nil {
+			y = new(big.Int)
+		}
+		// non-positive exponents also use default Exp function
+		if useDefault || y.Sign() <= 0 {
+			ret[i] = new(big.Int).Exp(x, y, m)
+			continue
+		}
+		yWords = append(yWords, newNat(y))
+		indices = append(indices, i)
+	}
+	if len(yWords) == 0 {
+		return ret
+	}
+
+	xWords, mWords := newNat(x), newNat(m)
+	power0, power1, k0, numWords := montgomerySetup(xWords, mWords)
+	z := multiMontgomeryWindowed(mWords, power0, power1, k0, numWords, yWords, nfoldWindowWidth, nil)
+	for j, i := range indices {
+		zWords := assembleAndConvert(z[j], nil, mWords, k0, numWords).norm()
+		ret[i] = new(big.Int).SetBits(zWords.intBits())
+	}
+	return ret
+}
+
+// ExpSeqProduct computes the product of x**exps[i] mod |m|, i.e., x**(sum of exps) mod |m|.
+// The squarings of x are shared by all the exponents, whose results are multiplied in the Montgomery
+// domain into a single output. Exponents equal to 0 or 1, including nil ones, skip the shared loop.
+// An empty exps gives the empty product 1 mod |m|.
+// If an exponent is negative, the sum is computed first and passed to the default Exp function.
+// Each term still costs its own multiplications, so summing the exponents first and running a single
+// exponentiation is cheaper whenever all the exponents are at hand, see BenchmarkExpSeqProduct.
+//
+// ExpSeqProduct is not a cryptographically constant-time operation.
+func ExpSeqProduct(x, m *big.Int, exps []*big.Int) *big.Int {
+	// make sure x > 1, m is not nil, m > 0, m is odd and no exponent is negative,
+	// otherwise, use default Exp function
+	useDefault := x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1
+	for _, y := range exps {
+		if y != nil && y.Sign() < 0 {
+			useDefault = true
+		}
+	}
+	if useDefault {
+		sum := new(big.Int)
+		for _, y := range exps {
+			if y != nil {
+				sum.Add(sum, y)
+			}
+		}
+		return new(big.Int).Exp(x, sum, m)
+	}
+
+	xWords, mWords := newNat(x), newNat(m)
+	power0, power1, k0, numWords := montgomerySetup(xWords, mWords)
+	prod := nat(nil).make(numWords)
+	copy(prod, power0)
+	temp := nat(nil).make(numWords)
+	var yWords []nat
+	for _, y := range exps {
+		switch {
+		case y == nil || y.Sign() == 0:
+		case y.Cmp(big1) == 0:
+			temp = temp.montgomery(prod, power1, mWords, k0, numWords)
+			prod, temp = temp, prod
+		default:
+			yWords = append(yWords, newNat(y))
+		}
+	}
+	z := multiMontgomery(mWords, power0, power1, k0, numWords, yWords)
+	zWords := assembleAndConvert(prod, z, mWords, k0, numWords).norm()
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// MultiExp computes the product of xs[i]**ys[i] mod |m|.
+// A term with a nil base is skipped, and a nil exponent is treated as 0, so its term is 1.
+// Without any term, e.g., for empty xs and ys, the result is the empty product 1 mod |m|.
+// Like big.Int.Exp, MultiExp returns nil if a term has a negative exponent and its base is not invertible mod m.
+// MultiExp panics if xs and ys have different lengths.
+//
+// MultiExp is not a cryptographically constant-time operation.
+func MultiExp(xs, ys []*big.Int, m *big.Int) *big.Int {
+	if len(xs) != len(ys) {
+		panic("invalid input: xs and ys have different lengths")
+	}
+	// make sure m is not nil, m > 1 and m is odd, otherwise, use default Exp function
+	if m == nil || m.Cmp(big1) <= 0 || m.Bit(0) != 1 {
+		return defaultMultiExp(xs, ys, m)
+	}
+	mWords := newNat(m)
+	k0, RR, numWords := montgomeryConstants(mWords)
+	prod := power0Of(RR, mWords, k0, numWords)
+	temp := nat(nil).make(numWords)
+	for i := range xs {
+		x, y := xs[i], ys[i]
+		if x == nil || y == nil || y.Sign() == 0 {
+			continue
+		}
+		var term nat
+		if x.Cmp(big1) <= 0 || y.Sign() < 0 {
+			// the default Exp function, converted into the Montgomery form
+			z := new(big.Int).Exp(x, y, m)
+			if z == nil {
+				return nil
+			}
+			_, term = montgomeryPowers(newNat(z), mWords, k0, RR, numWords)
+		} else {
+			power0, power1 := montgomeryPowers(newNat(x), mWords, k0, RR, numWords)
+			term = multiMontgomery(mWords, power0, power1, k0, numWords, []nat{newNat(y)})[0]
+		}
+		temp = temp.montgomery(prod, term, mWords, k0, numWords)
+		prod, temp = temp, prod
+	}
+	zWords := assembleAndConvert(prod, nil, mWords, k0, numWords).norm()
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// defaultMultiExp computes the product of MultiExp with the default Exp function of big int.
+func defaultMultiExp(xs, ys []*big.Int, m *big.Int) *big.Int {
+	mAbs := new(big.Int)
+	if m != nil {
+		mAbs.Abs(m)
+	}
+	prod := big.NewInt(1)
+	for i := range xs {
+		if xs[i] == nil || ys[i] == nil {
+			continue
+		}
+		z := new(big.Int).Exp(xs[i], ys[i], m)
+		if z == nil {
+			return nil
+		}
+		prod.Mul(prod, z)
+		if mAbs.Sign() != 0 {
+			prod.Mod(prod, mAbs)
+		}
+	}
+	if mAbs.Sign() != 0 {
+		prod.Mod(prod, mAbs)
+	}
+	return prod
+}
+
+// ExpProduct computes x**(factors[0]*factors[1]*...) mod |m| without building the product of the factors,
+// e.g., for an accumulator witness over many small primes. The result is raised to each factor in turn
+// and stays in the Montgomery domain between the factors, so the cost is O(sum of the factor bit lengths)
+// multiplications and the memory is that of a few residues, instead of materializing a gigantic exponent,
+// see BenchmarkExpProduct.
+// A nil factor is treated as 0, and an empty factors gives x mod |m|.
+// If a factor is negative, the product is computed first and passed to the default Exp function.
+//
+// ExpProduct is not a cryptographically constant-time operation.
+func ExpProduct(x *big.Int, factors []*big.Int, m *big.Int) *big.Int {
+	// make sure x > 1, m is not nil, m > 0, m is odd and no factor is negative,
+	// otherwise, use default Exp function
+	useDefault := x.Cmp(big1) <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1
+	for _, y := range factors {
+		if y != nil && y.Sign() < 0 {
+			useDefault = true
+		}
+	}
+	if useDefault {
+		prod := big.NewInt(1)
+		for _, y := range factors {
+			prod.Mul(prod, exponentOrZero(y))
+		}
+		return new(big.Int).Exp(x, prod, m)
+	}
+
+	xWords, mWords := newNat(x), newNat(m)
+	power0, power1, k0, numWords := montgomerySetup(xWords, mWords)
+	// base holds the result so far, squared in place for each factor, and the buffers are reused
+	base := nat(nil).make(numWords)
+	copy(base, power1)
+	z := nat(nil).make(numWords)
+	temp := nat(nil).make(numWords)
+	for _, y := range factors {
+		copy(z, power0)
+		if y == nil || y.Sign() == 0 {
+			base, z = z, base
+			continue
+		}
+		yWords := newNat(y)
+		topBit := yWords.bitLen()
+		for i := 0; i < topBit; i++ {
+			if yWords[i/_W]&masks[i%_W] != 0 {
+				temp = temp.montgomery(z, base, mWords, k0, numWords)
+				z, temp = temp, z
+			}
+			// no squaring is needed after the highest set bit
+			if i+1 < topBit {
+				temp = temp.montgomery(base, base, mWords, k0, numWords)
+				base, temp = temp, base
+			}
+		}
+		base, z = z, base
+	}
+	zWords := assembleAndConvert(base, nil, mWords, k0, numWords).norm()
+	return new(big.Int).SetBits(zWords.intBits())
+}
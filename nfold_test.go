@@ -0,0 +1,174 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+// nfoldTestInputs returns real values interspersed with nils
+func nfoldTestInputs() []*big.Int {
+	getBenchParameters(1)
+	x4 := getDifferentBenchParameters(4)
+	return []*big.Int{nil, x4[0], x4[1], nil, big.NewInt(0), x4[2], big.NewInt(1), nil, x4[3]}
+}
+
+func TestNfoldExp(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	ys := nfoldTestInputs()
+	for _, m := range []*big.Int{n, big.NewInt(1), big.NewInt(1000)} {
+		got := NfoldExp(g, m, ys)
+		if len(got) != len(ys) {
+			t.Fatalf("NfoldExp returned %d results for %d exponents", len(got), len(ys))
+		}
+		for i, y := range ys {
+			if y == nil {
+				y = new(big.Int)
+			}
+			if want := new(big.Int).Exp(g, y, m); got[i].Cmp(want) != 0 {
+				t.Errorf("Wrong result for NfoldExp mod %v at index %d", m, i)
+			}
+		}
+	}
+}
+
+func TestMultiExp(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	ys := nfoldTestInputs()
+	xs := make([]*big.Int, len(ys))
+	for i := range xs {
+		// nil bases at different positions from the nil exponents
+		if i%4 != 2 {
+			xs[i] = new(big.Int).Add(g, big.NewInt(int64(i)))
+		}
+	}
+	xs[6] = big.NewInt(1)
+	for _, m := range []*big.Int{n, big.NewInt(1), big.NewInt(1000)} {
+		want := big.NewInt(1)
+		for i := range xs {
+			if xs[i] == nil || ys[i] == nil {
+				continue
+			}
+			want.Mul(want, new(big.Int).Exp(xs[i], ys[i], m))
+			want.Mod(want, m)
+		}
+		if got := MultiExp(xs, ys, m); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for MultiExp mod %v", m)
+		}
+	}
+
+	// negative exponents need invertible bases
+	inv := MultiExp([]*big.Int{big.NewInt(3), nil}, []*big.Int{big.NewInt(-2), big.NewInt(5)}, n)
+	if want := new(big.Int).Exp(big.NewInt(3), big.NewInt(-2), n); inv.Cmp(want) != 0 {
+		t.Errorf("Wrong result for MultiExp with a negative exponent")
+	}
+	if got := MultiExp([]*big.Int{big.NewInt(5)}, []*big.Int{big.NewInt(-1)}, big.NewInt(15)); got != nil {
+		t.Errorf("MultiExp = %v for a non-invertible base, want nil", got)
+	}
+}
+
+func TestExpSeqProduct(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	exps := nfoldTestInputs()
+	exps = append(exps, big.NewInt(1))
+	for _, tc := range []struct {
+		exps []*big.Int
+		m    *big.Int
+	}{
+		{exps, n},
+		{exps, big.NewInt(1000)},
+		{[]*big.Int{big.NewInt(1), nil, big.NewInt(0)}, n},
+		{[]*big.Int{exps[1], big.NewInt(-3)}, n},
+		{nil, n},
+	} {
+		sum := new(big.Int)
+		for _, y := range tc.exps {
+			if y != nil {
+				sum.Add(sum, y)
+			}
+		}
+		if got, want := ExpSeqProduct(g, tc.m, tc.exps), new(big.Int).Exp(g, sum, tc.m); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpSeqProduct of %d exponents mod %v", len(tc.exps), tc.m)
+		}
+	}
+}
+
+// smallPrimes returns the first n primes above start.
+func smallPrimes(start int64, n int) []*big.Int {
+	primes := make([]*big.Int, 0, n)
+	for p := big.NewInt(start); len(primes) < n; p.Add(p, big1) {
+		if p.ProbablyPrime(20) {
+			primes = append(primes, new(big.Int).Set(p))
+		}
+	}
+	return primes
+}
+
+func TestExpProduct(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	primes := smallPrimes(1<<20, 50)
+	for _, tc := range []struct {
+		x       *big.Int
+		factors []*big.Int
+		m       *big.Int
+	}{
+		{g, primes, n},
+		{g, append([]*big.Int{xList[0]}, primes[:3]...), n},
+		{g, primes, big.NewInt(1000)},
+		{g, []*big.Int{primes[0], nil, primes[1]}, n},
+		{g, []*big.Int{primes[0], big.NewInt(-3)}, n},
+		{n, primes[:2], n},
+		{big1, primes, n},
+		{g, nil, n},
+	} {
+		prod := big.NewInt(1)
+		for _, y := range tc.factors {
+			prod.Mul(prod, exponentOrZero(y))
+		}
+		if got, want := ExpProduct(tc.x, tc.factors, tc.m), new(big.Int).Exp(tc.x, prod, tc.m); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for ExpProduct of %d factors mod %v", len(tc.factors), tc.m)
+		}
+	}
+}
+
+func TestNfoldDegenerate(t *testing.T) {
+	g, n, xList := getBenchParameters(1)
+	zeros := []*big.Int{big.NewInt(0), nil, big.NewInt(0)}
+	for _, m := range []*big.Int{n, big.NewInt(1)} {
+		// the empty product and the all-zero exponents
+		one := new(big.Int).Exp(g, big.NewInt(0), m)
+		for name, got := range map[string]*big.Int{
+			"MultiExp(empty)":      MultiExp(nil, nil, m),
+			"ExpSeqProduct(empty)": ExpSeqProduct(g, m, []*big.Int{}),
+			"MultiExp(zeros)":      MultiExp([]*big.Int{g, g, g}, zeros, m),
+			"ExpSeqProduct(zeros)": ExpSeqProduct(g, m, zeros),
+		} {
+			if got.Cmp(one) != 0 {
+				t.Errorf("%s = %v mod %v, want %v", name, got, m, one)
+			}
+		}
+		for _, z := range append(NfoldExp(g, m, zeros), BatchExp(g, m, zeros)...) {
+			if z.Cmp(one) != 0 {
+				t.Errorf("a zero exponent gave %v mod %v, want %v", z, m, one)
+			}
+		}
+
+		// a single exponent matches the plain Exp
+		want := new(big.Int).Exp(g, xList[0], m)
+		single := []*big.Int{xList[0]}
+		for name, got := range map[string]*big.Int{
+			"NfoldExp":      NfoldExp(g, m, single)[0],
+			"BatchExp":      BatchExp(g, m, single)[0],
+			"MultiExp":      MultiExp([]*big.Int{g}, single, m),
+			"ExpSeqProduct": ExpSeqProduct(g, m, single),
+		} {
+			if got.Cmp(want) != 0 {
+				t.Errorf("%s of a single exponent mod %v differs from Exp", name, m)
+			}
+		}
+	}
+	for _, empty := range [][]*big.Int{NfoldExp(g, n, nil), BatchExp(g, n, []*big.Int{})} {
+		if empty == nil || len(empty) != 0 {
+			t.Errorf("got %v for no exponents, want an empty slice", empty)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"math/big"
 	"math/bits"
+	"runtime"
 )
 
 // PreTable is the pre-computation table for multi-exponentiation
@@ -14,6 +15,10 @@ type PreTable struct {
 	Modulus   *big.Int
 	TableSize int
 	table     [][_W]nat
+	// the Montgomery constants of the modulus, shared by every exponentiation with the table
+	power0   nat
+	k0       Word
+	numWords int
 }
 
 func GetTableSize(table *PreTable) {
@@ -22,6 +27,7 @@ func GetTableSize(table *PreTable) {
 }
 
 // NewPrecomputeTable creates a pre-computation table for multi-exponentiation
+// It returns nil for invalid inputs and for a base congruent to 1 modulo modular.
 func NewPrecomputeTable(base, modular *big.Int, tableSize int) *PreTable {
 	if tableSize <= 0 {
 		return nil
@@ -43,7 +49,11 @@ func NewPrecomputeTable(base, modular *big.Int, tableSize int) *PreTable {
 	// x > 1
 
 	m := newNat(modular) // m.abs may be nil for m == 0
-	_, power1, k0, numWords := montgomerySetup(x, m)
+	// a base congruent to 1 would only produce a table of ones
+	if _, r := nat(nil).div(nil, x, m); len(r) == 1 && r[0] == 1 {
+		return nil
+	}
+	power0, power1, k0, numWords := montgomerySetup(x, m)
 	if numWords == 0 {
 		return nil
 	}
@@ -74,7 +84,114 @@ func NewPrecomputeTable(base, modular *big.Int, tableSize int) *PreTable {
 		Modulus:   modular,
 		TableSize: tableSize,
 		table:     preTable,
+		power0:    power0,
+		k0:        k0,
+		numWords:  numWords,
+	}
+}
+
+// extend returns a table of tableSize rows for the same base and modulus, sharing the rows of p,
+// which is left unchanged, and computing the new rows from its last entry. p must be built by
+// NewPrecomputeTable; it is returned as is if it already has tableSize rows.
+func (p *PreTable) extend(tableSize int) *PreTable {
+	if tableSize <= p.TableSize {
+		return p
+	}
+	m := newNat(p.Modulus)
+	_, k0, numWords := p.montgomerySetup(m)
+	preTable := make([][_W]nat, tableSize)
+	copy(preTable, p.table)
+
+	temp := nat(nil).make(numWords)
+	last := p.table[p.TableSize-1][_W-1]
+	squaredPower := nat(nil).make(numWords)
+	squaredPower = squaredPower.montgomery(last, last, m, k0, numWords)
+	for i := p.TableSize; i < tableSize; i++ {
+		for j := 0; j < _W; j++ {
+			preTable[i][j] = nat(nil).set(squaredPower)
+			temp = temp.montgomery(squaredPower, squaredPower, m, k0, numWords)
+			squaredPower, temp = temp, squaredPower
+		}
+	}
+
+	return &PreTable{
+		Base:      p.Base,
+		Modulus:   p.Modulus,
+		TableSize: tableSize,
+		table:     preTable,
+		power0:    p.power0,
+		k0:        k0,
+		numWords:  numWords,
+	}
+}
+
+// montgomerySetup returns the Montgomery form of 1, k0 and the number of words of the modulus m of the table,
+// as computed once by NewPrecomputeTable. They are computed from m for a table built otherwise.
+// The returned power0 is shared and must not be modified.
+func (p *PreTable) montgomerySetup(m nat) (power0 nat, k0 Word, numWords int) {
+	if p.power0 != nil {
+		return p.power0, p.k0, p.numWords
+	}
+	k0, RR, numWords := montgomeryConstants(m)
+	return power0Of(RR, m, k0, numWords), k0, numWords
+}
+
+// PowerAt returns x**(2**bitIndex) mod m, i.e., the table entry used for the bit bitIndex of an exponent,
+// converted out of the Montgomery form. It returns nil if bitIndex is not covered by the table.
+func (p *PreTable) PowerAt(bitIndex int) *big.Int {
+	if bitIndex < 0 || bitIndex >= p.TableSize*_W {
+		return nil
+	}
+	m := newNat(p.Modulus)
+	k0, _, numWords := montgomeryConstants(m)
+	entry := nat(nil).set(p.table[bitIndex/_W][bitIndex%_W])
+	z := assembleAndConvert(entry, nil, m, k0, numWords).norm()
+	return new(big.Int).SetBits(z.intBits())
+}
+
+// checkPreTable panics if preTable is nil or was not created for the base x and the modulus m.
+// A mismatched table is a programming error, so it panics even for the inputs handled by the default Exp function.
+func checkPreTable(preTable *PreTable, x, m *big.Int) {
+	if preTable == nil {
+		panic("precompute table is nil")
+	}
+	checkTableInputs(preTable.Base, preTable.Modulus, x, m)
+}
+
+// checkTableInputs panics if x and m are not the base and the modulus a pre-computation table was created for.
+// It is shared by the checks of all the table types, which first panic on a nil table themselves.
+func checkTableInputs(base, modulus, x, m *big.Int) {
+	if x == nil || base.Cmp(x) != 0 {
+		panic("precompute table not match: invalid base")
+	}
+	if m == nil || modulus.Cmp(m) != 0 {
+		panic("precompute table not match: invalid modulus")
+	}
+}
+
+// ExpPrecomputed computes x**y mod |m| using the pre-computation table in a single goroutine.
+// ExpPrecomputed is not a cryptographically constant-time operation.
+func ExpPrecomputed(x, y, m *big.Int, preTable *PreTable) *big.Int {
+	checkPreTable(preTable, x, m)
+	y = exponentOrZero(y)
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return new(big.Int).Exp(x, y, m)
+	}
+	xWords, yWords, mWords := newNat(x), newNat(y), newNat(m)
+	zWords := expNNMontgomeryPrecomputed(xWords, yWords, mWords, preTable)
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// expNNMontgomeryPrecomputed calculates x**y mod m with the pre-computation table in the calling goroutine.
+func expNNMontgomeryPrecomputed(x, y, m nat, preTable *PreTable) nat {
+	if len(y) > preTable.TableSize {
+		panic("precompute table too small for the exponent")
 	}
+	power0, k0, numWords := preTable.montgomerySetup(m)
+	z := multiMontgomeryPrecomputed(m, power0, k0, numWords, []nat{y}, preTable)
+	return assembleAndConvert(z[0], nil, m, k0, numWords).norm()
 }
 
 func (p *PreTable) routineExpNNMontgomery(ctx context.Context, power0, y, m nat, k0 Word, wordChunkSize int,
@@ -94,15 +211,7 @@ func (p *PreTable) routineExpNNMontgomery(ctx context.Context, power0, y, m nat,
 			if r > len(y) {
 				r = len(y)
 			}
-			for i := l; i < r; i++ {
-				for j := 0; j < _W; j++ {
-					if (y[i] & masks[j]) != masks[j] {
-						continue
-					}
-					temp = temp.montgomery(ret, p.table[i][j], m, k0, numWords)
-					ret, temp = temp, ret
-				}
-			}
+			ret, temp = p.montgomeryRange(ret, temp, y, m, k0, l, r)
 		default: // we get to here only when we receive nothing from the channel
 			if receivedTask {
 				outputs <- ret
@@ -114,9 +223,91 @@ func (p *PreTable) routineExpNNMontgomery(ctx context.Context, power0, y, m nat,
 	}
 }
 
+// montgomeryRange multiplies z by the table entries of the set bits in the words y[l:r], i.e., by x**(y[l:r]<<(l*_W))
+// in Montgomery form. temp is the scratch space, z and temp are swapped while multiplying, so both are returned.
+func (p *PreTable) montgomeryRange(z, temp, y, m nat, k0 Word, l, r int) (nat, nat) {
+	numWords := len(m)
+	for i := l; i < r; i++ {
+		for j := 0; j < _W; j++ {
+			if (y[i] & masks[j]) != masks[j] {
+				continue
+			}
+			temp = temp.montgomery(z, p.table[i][j], m, k0, numWords)
+			z, temp = temp, z
+		}
+	}
+	return z, temp
+}
+
+// ExpPartials splits y into numParts contiguous ranges of words and returns the partial products of x**y mod |m|
+// for each range, e.g., to compute them on different machines. The partial products are in Montgomery form
+// (and may not be fully reduced), they are only meaningful to CombinePartials, which multiplies them into the
+// same result as ExpParallel.
+// ExpPartials panics if preTable does not match x and m, if m is not odd or if y is negative.
+func ExpPartials(x, y, m *big.Int, preTable *PreTable, numParts int) []*big.Int {
+	checkPreTable(preTable, x, m)
+	if m.Bit(0) != 1 {
+		panic("The input modular is not an odd number")
+	}
+	if y.Sign() < 0 {
+		panic("invalid y: negative value")
+	}
+	if numParts <= 0 {
+		numParts = 1
+	}
+	yWords, mWords := newNat(y), newNat(m)
+	if len(yWords) > preTable.TableSize {
+		panic("precompute table too small for the exponent")
+	}
+	power0, k0, numWords := preTable.montgomerySetup(mWords)
+
+	partSize := (len(yWords) + numParts - 1) / numParts
+	parts := make([]*big.Int, numParts)
+	temp := nat(nil).make(numWords)
+	for i := range parts {
+		z := nat(nil).make(numWords)
+		copy(z, power0)
+		l, r := i*partSize, (i+1)*partSize
+		if r > len(yWords) {
+			r = len(yWords)
+		}
+		if l < r {
+			z, temp = preTable.montgomeryRange(z, temp, yWords, mWords, k0, l, r)
+		}
+		parts[i] = new(big.Int).SetBits(z.norm().intBits())
+	}
+	return parts
+}
+
+// CombinePartials multiplies the partial products returned by ExpPartials for the modulus m and
+// returns the result of the exponentiation.
+func CombinePartials(parts []*big.Int, m *big.Int) *big.Int {
+	if m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		panic("The input modular is not an odd number")
+	}
+	if len(parts) == 0 {
+		// the empty product
+		return new(big.Int).Mod(big1, m)
+	}
+	mWords := newNat(m)
+	k0, _, numWords := montgomeryConstants(mWords)
+	set := make([]nat, len(parts))
+	for i := range parts {
+		part := newNat(parts[i])
+		if len(part) > numWords {
+			panic("invalid partial product: longer than the modulus")
+		}
+		set[i] = make(nat, numWords)
+		copy(set[i], part)
+	}
+	zWords := assembleAndConvert(set[0], set[1:], mWords, k0, numWords).norm()
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
 // FourfoldExpPrecomputedParallel sets z1 = x**y1 mod |m|, z2 = x**y2 mod |m| ... (i.e. the sign of m is ignored), and returns z1, z2...
 // In construction, many panic conditions. Use at your own risk!
-// Use at most 4 threads for now.
+// Use at most 4 threads for now, or only the calling goroutine if GOMAXPROCS(0) == 1.
+// The Montgomery constants of m are read from preTable instead of being computed for every call.
 // FourfoldExpPrecomputedParallel is not a cryptographically constant-time operation.
 func FourfoldExpPrecomputedParallel(x, m *big.Int, y4 [4]*big.Int, preTable *PreTable) [4]*big.Int {
 	if x.Sign() < 0 {
@@ -144,6 +335,10 @@ func FourfoldExpPrecomputedParallel(x, m *big.Int, y4 [4]*big.Int, preTable *Pre
 		panic("The input table does not match the input")
 	}
 	xWords, mWords := newNat(x), newNat(m)
+	// a single core gains nothing from the goroutines
+	if runtime.GOMAXPROCS(0) == 1 {
+		return fourfoldExpNNMontgomeryPrecomputed(xWords, mWords, y4, preTable)
+	}
 	return fourfoldExpNNMontgomeryPrecomputedParallel(xWords, mWords, y4, preTable)
 }
 
@@ -183,47 +378,32 @@ func FourfoldExpPrecomputed(x, m *big.Int, y4 [4]*big.Int, preTable *PreTable) [
 // fourfoldExpNNMontgomery calculates x**y1 mod m and x**y2 mod m x**y3 mod m and x**y4 mod m
 // Uses Montgomery representation.
 func fourfoldExpNNMontgomeryPrecomputedParallel(x, m nat, y4 [4]*big.Int, preTable *PreTable) [4]*big.Int {
-	power0, _, k0, numWords := montgomerySetup(x, m)
-
-	gcwList := fourfoldGCW([4]nat{newNat(y4[0]), newNat(y4[1]), newNat(y4[2]), newNat(y4[3])})
-
-	var cm012, cm013, cm023, cm123 nat
-	cm012 = threefoldGCW([3]nat{gcwList[0], gcwList[1], gcwList[2]})
-	cm013 = threefoldGCW([3]nat{gcwList[0], gcwList[1], gcwList[3]})
-	cm023 = threefoldGCW([3]nat{gcwList[0], gcwList[2], gcwList[3]})
-	cm123 = threefoldGCW([3]nat{gcwList[1], gcwList[2], gcwList[3]})
-
-	var cm01, cm23, cm02, cm13, cm03, cm12 nat
-	gcwList[0], gcwList[1], cm01 = gcw(gcwList[0], gcwList[1])
-	gcwList[2], gcwList[3], cm23 = gcw(gcwList[2], gcwList[3])
-	gcwList[0], gcwList[2], cm02 = gcw(gcwList[0], gcwList[2])
-	gcwList[1], gcwList[3], cm13 = gcw(gcwList[1], gcwList[3])
-	gcwList[0], gcwList[3], cm03 = gcw(gcwList[0], gcwList[3])
-	gcwList[1], gcwList[2], cm12 = gcw(gcwList[1], gcwList[2])
+	power0, k0, numWords := preTable.montgomerySetup(m)
+
+	chains := fourfoldChains([4]nat{newNat(y4[0]), newNat(y4[1]), newNat(y4[2]), newNat(y4[3])})
 	var c4 [4]chan []nat
 	for i := range c4 {
 		c4[i] = make(chan []nat)
 	}
-	go multiMontgomeryPrecomputedChan(m, power0, k0, numWords, gcwList[:4], preTable, c4[0])
-	go multiMontgomeryPrecomputedChan(m, power0, k0, numWords, []nat{gcwList[4], cm012, cm013, cm023}, preTable, c4[1])
-	go multiMontgomeryPrecomputedChan(m, power0, k0, numWords, []nat{cm123, cm01, cm23, cm02}, preTable, c4[2])
-	go multiMontgomeryPrecomputedChan(m, power0, k0, numWords, []nat{cm13, cm03, cm12}, preTable, c4[3])
+	go multiMontgomeryPrecomputedChan(m, power0, k0, numWords, chains[0:4], preTable, c4[0])
+	go multiMontgomeryPrecomputedChan(m, power0, k0, numWords, chains[4:8], preTable, c4[1])
+	go multiMontgomeryPrecomputedChan(m, power0, k0, numWords, chains[8:12], preTable, c4[2])
+	go multiMontgomeryPrecomputedChan(m, power0, k0, numWords, chains[12:15], preTable, c4[3])
 
 	var z []nat
 	for i := range c4 {
 		z = append(z, <-c4[i]...)
 	}
-	// z := multiMontgomeryPrecomputed(RR, m, powers[0], powers[1], k0, numWords, append(gcwList, cm012, cm013, cm023, cm123, cm01, cm23, cm02, cm13, cm03, cm12), preTable)
 	// calculate the actual values
 
 	var outputs [4]chan nat
 	for i := range outputs {
 		outputs[i] = make(chan nat)
 	}
-	go assembleAndConvertChan(z[0], []nat{z[4], z[5], z[6], z[7], z[9], z[11], z[13]}, m, k0, numWords, outputs[0])
-	go assembleAndConvertChan(z[1], []nat{z[4], z[5], z[6], z[8], z[9], z[12], z[14]}, m, k0, numWords, outputs[1])
-	go assembleAndConvertChan(z[2], []nat{z[4], z[5], z[7], z[8], z[10], z[11], z[14]}, m, k0, numWords, outputs[2])
-	go assembleAndConvertChan(z[3], []nat{z[4], z[6], z[7], z[8], z[10], z[12], z[13]}, m, k0, numWords, outputs[3])
+	go assembleAndConvertChan(z[0], fourfoldAssemblySet(z, 0), m, k0, numWords, outputs[0])
+	go assembleAndConvertChan(z[1], fourfoldAssemblySet(z, 1), m, k0, numWords, outputs[1])
+	go assembleAndConvertChan(z[2], fourfoldAssemblySet(z, 2), m, k0, numWords, outputs[2])
+	go assembleAndConvertChan(z[3], fourfoldAssemblySet(z, 3), m, k0, numWords, outputs[3])
 
 	var ret [4]*big.Int
 	// normalize and set value
@@ -238,45 +418,31 @@ func fourfoldExpNNMontgomeryPrecomputedParallel(x, m nat, y4 [4]*big.Int, preTab
 // fourfoldExpNNMontgomery calculates x**y1 mod m and x**y2 mod m x**y3 mod m and x**y4 mod m
 // Uses Montgomery representation.
 func fourfoldExpNNMontgomeryPrecomputed(x, m nat, y4 [4]*big.Int, preTable *PreTable) [4]*big.Int {
-	power0, _, k0, numWords := montgomerySetup(x, m)
-
-	gcwList := fourfoldGCW([4]nat{newNat(y4[0]), newNat(y4[1]), newNat(y4[2]), newNat(y4[3])})
-
-	var cm012, cm013, cm023, cm123 nat
-	cm012 = threefoldGCW([3]nat{gcwList[0], gcwList[1], gcwList[2]})
-	cm013 = threefoldGCW([3]nat{gcwList[0], gcwList[1], gcwList[3]})
-	cm023 = threefoldGCW([3]nat{gcwList[0], gcwList[2], gcwList[3]})
-	cm123 = threefoldGCW([3]nat{gcwList[1], gcwList[2], gcwList[3]})
-
-	var cm01, cm23, cm02, cm13, cm03, cm12 nat
-	gcwList[0], gcwList[1], cm01 = gcw(gcwList[0], gcwList[1])
-	gcwList[2], gcwList[3], cm23 = gcw(gcwList[2], gcwList[3])
-	gcwList[0], gcwList[2], cm02 = gcw(gcwList[0], gcwList[2])
-	gcwList[1], gcwList[3], cm13 = gcw(gcwList[1], gcwList[3])
-	gcwList[0], gcwList[3], cm03 = gcw(gcwList[0], gcwList[3])
-	gcwList[1], gcwList[2], cm12 = gcw(gcwList[1], gcwList[2])
+	power0, k0, numWords := preTable.montgomerySetup(m)
+
+	chains := fourfoldChains([4]nat{newNat(y4[0]), newNat(y4[1]), newNat(y4[2]), newNat(y4[3])})
 	// var c4 [4]chan []nat
 	// for i := range c4 {
 	// 	c4[i] = make(chan []nat)
 	// }
-	// multiMontgomeryPrecomputedChan(m, power0, k0, numWords, gcwList[:4], preTable, c4[0])
-	// multiMontgomeryPrecomputedChan(m, power0, k0, numWords, []nat{gcwList[4], cm012, cm013, cm023}, preTable, c4[1])
-	// multiMontgomeryPrecomputedChan(m, power0, k0, numWords, []nat{cm123, cm01, cm23, cm02}, preTable, c4[2])
-	// multiMontgomeryPrecomputedChan(m, power0, k0, numWords, []nat{cm13, cm03, cm12}, preTable, c4[3])
+	// multiMontgomeryPrecomputedChan(m, power0, k0, numWords, chains[0:4], preTable, c4[0])
+	// multiMontgomeryPrecomputedChan(m, power0, k0, numWords, chains[4:8], preTable, c4[1])
+	// multiMontgomeryPrecomputedChan(m, power0, k0, numWords, chains[8:12], preTable, c4[2])
+	// multiMontgomeryPrecomputedChan(m, power0, k0, numWords, chains[12:15], preTable, c4[3])
 
 	// var z []nat
 	// for i := range c4 {
 	// 	z = append(z, <-c4[i]...)
 	// }
-	z := multiMontgomeryPrecomputed(m, power0, k0, numWords, append(gcwList[:], cm012, cm013, cm023, cm123, cm01, cm23, cm02, cm13, cm03, cm12), preTable)
+	z := multiMontgomeryPrecomputed(m, power0, k0, numWords, chains[:], preTable)
 	// calculate the actual values
 
 	var outputs [4]nat
 
-	outputs[0] = assembleAndConvert(z[0], []nat{z[4], z[5], z[6], z[7], z[9], z[11], z[13]}, m, k0, numWords)
-	outputs[1] = assembleAndConvert(z[1], []nat{z[4], z[5], z[6], z[8], z[9], z[12], z[14]}, m, k0, numWords)
-	outputs[2] = assembleAndConvert(z[2], []nat{z[4], z[5], z[7], z[8], z[10], z[11], z[14]}, m, k0, numWords)
-	outputs[3] = assembleAndConvert(z[3], []nat{z[4], z[6], z[7], z[8], z[10], z[12], z[13]}, m, k0, numWords)
+	outputs[0] = assembleAndConvert(z[0], fourfoldAssemblySet(z, 0), m, k0, numWords)
+	outputs[1] = assembleAndConvert(z[1], fourfoldAssemblySet(z, 1), m, k0, numWords)
+	outputs[2] = assembleAndConvert(z[2], fourfoldAssemblySet(z, 2), m, k0, numWords)
+	outputs[3] = assembleAndConvert(z[3], fourfoldAssemblySet(z, 3), m, k0, numWords)
 
 	var ret [4]*big.Int
 	// normalize and set value
@@ -303,11 +469,25 @@ func assembleAndConvert(prod nat, set []nat, mm nat, k0 Word, numWords int) nat
 	// convert to regular number
 	temp = temp.montgomery(prod, one, m, k0, numWords)
 	prod, temp = temp, prod
-	// one last reduction, just in case.
+	return finalReduce(prod, m)
+}
+
+// finalReduce applies the last reduction, just in case, to a result converted out of the Montgomery form.
+// montgomery keeps its results below R = 2**(numWords*_W) but not below 2m when the top word of m
+// has leading zero bits. Multiplying by one still gives prod < R*(m+1)/R, i.e., prod <= m,
+// so a single subtraction suffices whatever the top bits of m; the division is only a safeguard.
+// The reductions are counted for ReductionStats.
+func finalReduce(prod, m nat) nat {
+	reductionCounters.conversions.Add(1)
 	if prod.cmp(m) >= 0 {
+		reductionCounters.subtractions.Add(1)
 		prod = prod.sub(prod, m)
 		if prod.cmp(m) >= 0 {
-			_, prod = nat(nil).div(nil, prod, m)
+			reductionCounters.divisions.Add(1)
+			s := getDivScratch()
+			s.q, s.r = s.q.div(s.r, prod, m)
+			prod = prod.set(s.r)
+			putDivScratch(s)
 		}
 	}
 	return prod
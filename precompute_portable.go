@@ -0,0 +1,162 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// portableRowBits is the number of bits per row of a PortablePreTable, i.e., the smallest
+// word size among the supported platforms.
+const portableRowBits = 32
+
+// PortablePreTable is a pre-computation table that can be shared between platforms of different word sizes.
+// Unlike PreTable, whose rows are _W bits wide and whose entries are in the Montgomery form of the local
+// word size, it stores x**(2**i) mod m as plain integers in rows of 32 bits. The exponentiation converts
+// the result back from the local Montgomery form once, instead of converting every entry.
+type PortablePreTable struct {
+	Base    *big.Int
+	Modulus *big.Int
+	// TableSize is the number of 32-bit rows
+	TableSize int
+	table     [][portableRowBits]nat
+}
+
+// NewPortablePrecomputeTable creates a portable pre-computation table with tableSize rows of 32 bits,
+// supporting exponents up to 32*tableSize bits on every platform.
+func NewPortablePrecomputeTable(base, modular *big.Int, tableSize int) *PortablePreTable {
+	if tableSize <= 0 {
+		return nil
+	}
+	if base == nil || modular == nil {
+		return nil
+	}
+	if base.Sign() <= 0 || modular.Sign() <= 0 || modular.Bit(0) != 1 {
+		return nil
+	}
+	x, m := newNat(base), newNat(modular)
+	if _, r := nat(nil).div(nil, x, m); len(r) == 1 && r[0] == 1 {
+		return nil
+	}
+	_, power1, k0, numWords := montgomerySetup(x, m)
+
+	var temp, squaredPower nat
+	temp = temp.make(numWords)
+	squaredPower = squaredPower.make(numWords)
+	copy(squaredPower, power1)
+	table := make([][portableRowBits]nat, tableSize)
+	for i := range table {
+		for j := range table[i] {
+			// store the plain value, which does not depend on the word size
+			table[i][j] = assembleAndConvert(nat(nil).set(squaredPower), nil, m, k0, numWords).norm()
+			temp = temp.montgomery(squaredPower, squaredPower, m, k0, numWords)
+			squaredPower, temp = temp, squaredPower
+		}
+	}
+
+	return &PortablePreTable{
+		Base:      base,
+		Modulus:   modular,
+		TableSize: tableSize,
+		table:     table,
+	}
+}
+
+// PortablePreTableFromPowers creates a portable pre-computation table from the powers x**(2**i) mod m
+// returned by Powers, e.g., after they have been transferred from a platform with a different word size.
+// The number of powers must be a multiple of 32. It returns nil unless the first power is base mod modular
+// and each power is the square of the previous one mod modular, so that powers transferred for another base
+// or modulus, or corrupted on the way, are rejected instead of giving wrong results.
+func PortablePreTableFromPowers(base, modular *big.Int, powers []*big.Int) *PortablePreTable {
+	if len(powers) == 0 || len(powers)%portableRowBits != 0 {
+		return nil
+	}
+	if base == nil || modular == nil || modular.Sign() <= 0 || modular.Bit(0) != 1 {
+		return nil
+	}
+	table := make([][portableRowBits]nat, len(powers)/portableRowBits)
+	want := new(big.Int).Mod(base, modular)
+	for i, power := range powers {
+		if power == nil || power.Cmp(want) != 0 {
+			return nil
+		}
+		table[i/portableRowBits][i%portableRowBits] = newNat(power)
+		want.Mul(power, power)
+		want.Mod(want, modular)
+	}
+	return &PortablePreTable{
+		Base:      base,
+		Modulus:   modular,
+		TableSize: len(table),
+		table:     table,
+	}
+}
+
+// Powers returns x**(2**i) mod m for every bit i covered by the table.
+func (p *PortablePreTable) Powers() []*big.Int {
+	powers := make([]*big.Int, 0, p.TableSize*portableRowBits)
+	for i := range p.table {
+		for j := range p.table[i] {
+			powers = append(powers, new(big.Int).SetBits(p.table[i][j].intBits()))
+		}
+	}
+	return powers
+}
+
+// ExpPortablePrecomputed computes x**y mod |m| using the portable pre-computation table.
+// ExpPortablePrecomputed is not a cryptographically constant-time operation.
+func ExpPortablePrecomputed(x, y, m *big.Int, preTable *PortablePreTable) *big.Int {
+	if preTable == nil {
+		panic("precompute table is nil")
+	}
+	checkTableInputs(preTable.Base, preTable.Modulus, x, m)
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return new(big.Int).Exp(x, y, m)
+	}
+	if y.BitLen() > preTable.TableSize*portableRowBits {
+		panic("precompute table too small for the exponent")
+	}
+	yWords, mWords := newNat(y), newNat(m)
+	zWords := preTable.expNN(yWords, mWords)
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// expNN calculates x**y mod m from the plain table entries.
+// Multiplying plain values with montgomery introduces a factor R**-1 = 2**(-n*_W) per multiplication,
+// so after c multiplications the product is corrected once by R**c.
+func (p *PortablePreTable) expNN(y, m nat) nat {
+	k0, RR, numWords := montgomeryConstants(m)
+
+	var z nat
+	temp := nat(nil).make(numWords)
+	entry := nat(nil).make(numWords)
+	c := 0
+	for i := 0; i < len(y)*_W; i++ {
+		if y[i/_W]&masks[i%_W] == 0 {
+			continue
+		}
+		// bit i of y is bit i%32 of the row i/32, whatever the word size is
+		entry.clear()
+		copy(entry, p.table[i/portableRowBits][i%portableRowBits])
+		if z == nil {
+			z = nat(nil).set(entry)
+			continue
+		}
+		temp = temp.montgomery(z, entry, m, k0, numWords)
+		z, temp = temp, z
+		c++
+	}
+
+	// z = x**y * R**-c, multiply by R**(c+2), the Montgomery form of R**(c+1),
+	// to get the Montgomery form of x**y
+	power0, power1 := montgomeryPowers(power0Of(RR, m, k0, numWords), m, k0, RR, numWords)
+	rc := multiMontgomery(m, power0, power1, k0, numWords, []nat{nat(nil).setWord(Word(c + 1))})
+	return assembleAndConvert(z, rc, m, k0, numWords).norm()
+}
+
+// power0Of returns R mod m = 2**(n*_W) mod m, i.e., the Montgomery form of 1.
+func power0Of(RR, m nat, k0 Word, numWords int) nat {
+	one := make(nat, numWords)
+	one[0] = 1
+	return nat(nil).montgomery(one, RR, m, k0, numWords)
+}
@@ -0,0 +1,127 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// TwoLevelPreTable is a pre-computation table storing only the coarse powers x**(2**(i*LevelBits)).
+// The powers in between are derived by up to LevelBits-1 squarings per exponentiation, so the table is
+// LevelBits times smaller than a PreTable covering the same exponents, at the cost of these squarings.
+// LevelBits = 1 stores every power like a PreTable, and larger values trade time for memory.
+type TwoLevelPreTable struct {
+	Base      *big.Int
+	Modulus   *big.Int
+	MaxBits   int
+	levelBits int
+	table     []nat
+}
+
+// NewTwoLevelPreTable creates a two-level pre-computation table supporting exponents up to maxBits bits,
+// with a coarse power every levelBits bits.
+// It returns nil for invalid inputs and for a base congruent to 1 modulo modular.
+func NewTwoLevelPreTable(base, modular *big.Int, maxBits, levelBits int) *TwoLevelPreTable {
+	if maxBits <= 0 || levelBits <= 0 {
+		return nil
+	}
+	if base == nil || modular == nil {
+		return nil
+	}
+	if base.Sign() <= 0 || modular.Sign() <= 0 || modular.Bit(0) != 1 {
+		return nil
+	}
+	x, m := newNat(base), newNat(modular)
+	// a base congruent to 1 would only produce a table of ones
+	if _, r := nat(nil).div(nil, x, m); len(r) == 1 && r[0] == 1 {
+		return nil
+	}
+	_, power1, k0, numWords := montgomerySetup(x, m)
+
+	var temp, squaredPower nat
+	temp = temp.make(numWords)
+	squaredPower = squaredPower.make(numWords)
+	copy(squaredPower, power1)
+	table := make([]nat, (maxBits+levelBits-1)/levelBits)
+	for i := range table {
+		table[i] = nat(nil).set(squaredPower)
+		if i == len(table)-1 {
+			break
+		}
+		for j := 0; j < levelBits; j++ {
+			temp = temp.montgomery(squaredPower, squaredPower, m, k0, numWords)
+			squaredPower, temp = temp, squaredPower
+		}
+	}
+
+	return &TwoLevelPreTable{
+		Base:      base,
+		Modulus:   modular,
+		MaxBits:   maxBits,
+		levelBits: levelBits,
+		table:     table,
+	}
+}
+
+// LevelBits returns the number of bits between two coarse powers of the table.
+func (p *TwoLevelPreTable) LevelBits() int {
+	return p.levelBits
+}
+
+// ExpTwoLevelPrecomputed computes x**y mod |m| using the two-level pre-computation table in a single goroutine.
+// ExpTwoLevelPrecomputed is not a cryptographically constant-time operation.
+func ExpTwoLevelPrecomputed(x, y, m *big.Int, preTable *TwoLevelPreTable) *big.Int {
+	if preTable == nil {
+		panic("precompute table is nil")
+	}
+	checkTableInputs(preTable.Base, preTable.Modulus, x, m)
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return new(big.Int).Exp(x, y, m)
+	}
+	if y.BitLen() > len(preTable.table)*preTable.levelBits {
+		panic("precompute table too small for the exponent")
+	}
+	zWords := preTable.expNN(newNat(y), newNat(m), y.BitLen())
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// expNN calculates x**y mod m for y of bitLen bits.
+// With y = sum of y_j * 2**j over the offsets j < LevelBits of the bits within each level,
+// acc[j] collects the coarse powers of the levels where bit j is set, and
+// x**y = prod acc[j]**(2**j) is evaluated from the highest offset down with LevelBits-1 squarings.
+func (p *TwoLevelPreTable) expNN(y, m nat, bitLen int) nat {
+	k0, _, numWords := montgomeryConstants(m)
+	acc := make([]nat, p.levelBits)
+	temp := nat(nil).make(numWords)
+	for i := 0; i < bitLen; i++ {
+		if y[i/_W]&masks[i%_W] == 0 {
+			continue
+		}
+		coarse, j := p.table[i/p.levelBits], i%p.levelBits
+		if acc[j] == nil {
+			acc[j] = nat(nil).set(coarse)
+			continue
+		}
+		temp = temp.montgomery(acc[j], coarse, m, k0, numWords)
+		acc[j], temp = temp, acc[j]
+	}
+
+	var z nat
+	for j := len(acc) - 1; j >= 0; j-- {
+		if z != nil {
+			temp = temp.montgomery(z, z, m, k0, numWords)
+			z, temp = temp, z
+		}
+		if acc[j] == nil {
+			continue
+		}
+		if z == nil {
+			z = acc[j]
+			continue
+		}
+		temp = temp.montgomery(z, acc[j], m, k0, numWords)
+		z, temp = temp, z
+	}
+	// y > 0, so some acc[j] is set
+	return assembleAndConvert(z, nil, m, k0, numWords).norm()
+}
@@ -0,0 +1,101 @@
+package multiexp
+
+import (
+	"math/big"
+)
+
+// WindowedPreTable is the pre-computation table for fixed-window exponentiation.
+// For the window width w, row p stores x**(d * 2**(p*w)) for every digit d in [1, 2**w),
+// so every w bits of the exponent cost a single multiplication instead of up to w.
+type WindowedPreTable struct {
+	Base    *big.Int
+	Modulus *big.Int
+	Width   int
+	maxBits int
+	table   [][]nat
+}
+
+// NewWindowedPreTable creates a windowed pre-computation table of the given window width from the per-bit
+// powers stored in preTable. The table has (2**width - 1) entries per width bits of the exponent,
+// i.e., (2**width - 1)/width times the size of preTable.
+func NewWindowedPreTable(preTable *PreTable, width int) *WindowedPreTable {
+	if preTable == nil || width <= 0 || width >= _W {
+		return nil
+	}
+	m := newNat(preTable.Modulus)
+	k0, _, numWords := montgomeryConstants(m)
+
+	maxBits := preTable.TableSize * _W
+	rows := (maxBits + width - 1) / width
+	table := make([][]nat, rows)
+	for p := range table {
+		// the digit 0 is never looked up
+		row := make([]nat, 1<<uint(width))
+		for b := 0; b < width && p*width+b < maxBits; b++ {
+			bit := p*width + b
+			power := preTable.table[bit/_W][bit%_W]
+			high := 1 << uint(b)
+			row[high] = nat(nil).set(power)
+			for d := 1; d < high; d++ {
+				row[high|d] = nat(nil).montgomery(row[d], power, m, k0, numWords)
+			}
+		}
+		table[p] = row
+	}
+
+	return &WindowedPreTable{
+		Base:    preTable.Base,
+		Modulus: preTable.Modulus,
+		Width:   width,
+		maxBits: maxBits,
+		table:   table,
+	}
+}
+
+// ExpPrecomputedWindowed computes x**y mod |m| using the windowed pre-computation table in a single goroutine.
+// ExpPrecomputedWindowed is not a cryptographically constant-time operation.
+func ExpPrecomputedWindowed(x, y, m *big.Int, preTable *WindowedPreTable) *big.Int {
+	if preTable == nil {
+		panic("precompute table is nil")
+	}
+	checkTableInputs(preTable.Base, preTable.Modulus, x, m)
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return new(big.Int).Exp(x, y, m)
+	}
+	if y.BitLen() > preTable.maxBits {
+		panic("precompute table too small for the exponent")
+	}
+	xWords, yWords, mWords := newNat(x), newNat(y), newNat(m)
+	power0, _, k0, numWords := montgomerySetup(xWords, mWords)
+
+	z := nat(nil).make(numWords)
+	copy(z, power0)
+	temp := nat(nil).make(numWords)
+	width := preTable.Width
+	for p := 0; p*width < len(yWords)*_W; p++ {
+		d := yWords.window(p*width, width)
+		if d == 0 {
+			continue
+		}
+		temp = temp.montgomery(z, preTable.table[p][d], mWords, k0, numWords)
+		z, temp = temp, z
+	}
+	zWords := assembleAndConvert(z, nil, mWords, k0, numWords).norm()
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// window returns the width bits of x starting at bit i; width must be less than _W.
+// Bits beyond the length of x are 0.
+func (x nat) window(i, width int) uint {
+	j, s := i/_W, uint(i%_W)
+	if j >= len(x) {
+		return 0
+	}
+	d := uint(x[j] >> s)
+	if s+uint(width) > _W && j+1 < len(x) {
+		d |= uint(x[j+1]) << (_W - s)
+	}
+	return d & (1<<uint(width) - 1)
+}
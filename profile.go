@@ -0,0 +1,23 @@
+package multiexp
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// ExponentProfile returns the bit length, the Hamming weight and the word length of |y|.
+//
+// They give the cost of the right-to-left exponentiation of this package: x**y takes bitLen-1
+// squarings and setBits multiplications, where the squarings are shared by all the exponents
+// of a DoubleExp or FourfoldExp call and a pre-computation table removes them entirely.
+// The common bits of several exponents are multiplied only once, so the sharing pays off when
+// the exponents are long and their set bits overlap. words is the TableSize a PreTable needs for y.
+func ExponentProfile(y *big.Int) (bitLen, setBits, words int) {
+	if y == nil {
+		return 0, 0, 0
+	}
+	for _, w := range y.Bits() {
+		setBits += bits.OnesCount(uint(w))
+	}
+	return y.BitLen(), setBits, len(y.Bits())
+}
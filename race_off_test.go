@@ -0,0 +1,7 @@
+//go:build !race
+// +build !race
+
+package multiexp
+
+// raceEnabled reports whether the tests run under the race detector.
+const raceEnabled = false
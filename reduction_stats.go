@@ -0,0 +1,32 @@
+package multiexp
+
+import (
+	"sync/atomic"
+)
+
+// reductionCounters counts the final reductions of all the results computed by this package.
+var reductionCounters struct {
+	conversions  atomic.Uint64
+	subtractions atomic.Uint64
+	divisions    atomic.Uint64
+}
+
+// ReductionCounts reports how the results converted out of the Montgomery form were finally reduced.
+type ReductionCounts struct {
+	// Conversions is the number of results converted out of the Montgomery form
+	Conversions uint64
+	// Subtractions is the number of results that were at least m and needed a subtraction
+	Subtractions uint64
+	// Divisions is the number of results still at least m after the subtraction, reduced by a division
+	Divisions uint64
+}
+
+// ReductionStats returns the reduction counts accumulated since the start of the program.
+// A zero Divisions over a run supports the claim that one subtraction always suffices.
+func ReductionStats() ReductionCounts {
+	return ReductionCounts{
+		Conversions:  reductionCounters.conversions.Load(),
+		Subtractions: reductionCounters.subtractions.Load(),
+		Divisions:    reductionCounters.divisions.Load(),
+	}
+}
@@ -0,0 +1,161 @@
+package multiexp
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+	"sync"
+)
+
+// defaultRegistryBytes is the default memory cap of DefaultTables, 256 MB.
+const defaultRegistryBytes = 256 << 20
+
+// DefaultTables is the global registry of pre-computation tables consulted by DefaultTables.Exp.
+var DefaultTables = NewTableRegistry(defaultRegistryBytes)
+
+// TableRegistry is a concurrency-safe cache of pre-computation tables looked up by (base, modulus).
+// When the memory of the registered tables exceeds the cap, the least recently used tables are evicted,
+// except the ones currently acquired by an exponentiation.
+// The memory is accounted from the sizes of the tables rather than runtime.MemStats,
+// which stops the world to be read.
+type TableRegistry struct {
+	mu       sync.Mutex
+	maxBytes int
+	used     int
+	lru      *list.List // of *registryEntry, the front is the most recently used
+	entries  map[[sha256.Size]byte]*list.Element
+}
+
+type registryEntry struct {
+	key   [sha256.Size]byte
+	table *PreTable
+	size  int
+	refs  int
+}
+
+// NewTableRegistry creates a table registry holding at most maxBytes bytes of tables that are not in use.
+func NewTableRegistry(maxBytes int) *TableRegistry {
+	return &TableRegistry{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// SetMaxBytes changes the memory cap of the registry, evicting tables if needed.
+func (r *TableRegistry) SetMaxBytes(maxBytes int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxBytes = maxBytes
+	r.evict()
+}
+
+// Register adds the table to the registry. A table already registered for the same base and modulus
+// is only replaced if the new one supports longer exponents.
+func (r *TableRegistry) Register(t *PreTable) {
+	if t == nil {
+		return
+	}
+	key := tableKey(t.Base, t.Modulus)
+	size := t.memSize()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.entries[key]; ok {
+		entry := e.Value.(*registryEntry)
+		if t.TableSize > entry.table.TableSize {
+			r.used += size - entry.size
+			entry.table, entry.size = t, size
+		}
+		r.lru.MoveToFront(e)
+	} else {
+		r.entries[key] = r.lru.PushFront(&registryEntry{key: key, table: t, size: size})
+		r.used += size
+	}
+	r.evict()
+}
+
+// Acquire returns the table registered for base and modulus, or nil if there is none.
+// The table cannot be evicted until release is called; release must be called exactly once.
+func (r *TableRegistry) Acquire(base, modulus *big.Int) (t *PreTable, release func()) {
+	key := tableKey(base, modulus)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[key]
+	if !ok {
+		return nil, func() {}
+	}
+	entry := e.Value.(*registryEntry)
+	entry.refs++
+	r.lru.MoveToFront(e)
+	var once sync.Once
+	return entry.table, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			entry.refs--
+			r.evict()
+		})
+	}
+}
+
+// Len returns the number of registered tables.
+func (r *TableRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lru.Len()
+}
+
+// Exp computes x**y mod |m|, using the table registered for x and m if there is one that is large enough.
+// Exp is not a cryptographically constant-time operation.
+func (r *TableRegistry) Exp(x, y, m *big.Int) *big.Int {
+	// make sure x > 1, m is not nil, m > 0, m is odd, and y is positive,
+	// otherwise, use default Exp function
+	if x.Cmp(big1) <= 0 || y.Sign() <= 0 || m == nil || m.Sign() <= 0 || m.Bit(0) != 1 {
+		return new(big.Int).Exp(x, y, m)
+	}
+	t, release := r.Acquire(x, m)
+	defer release()
+	if t != nil && len(y.Bits()) <= t.TableSize {
+		return ExpPrecomputed(x, y, m, t)
+	}
+	zWords := expNNMontgomery(newNat(x), newNat(y), newNat(m))
+	return new(big.Int).SetBits(zWords.intBits())
+}
+
+// evict removes the least recently used tables not in use until the cap is met.
+// r.mu must be held.
+func (r *TableRegistry) evict() {
+	for e := r.lru.Back(); e != nil && r.used > r.maxBytes; {
+		prev := e.Prev()
+		if entry := e.Value.(*registryEntry); entry.refs == 0 {
+			r.lru.Remove(e)
+			delete(r.entries, entry.key)
+			r.used -= entry.size
+		}
+		e = prev
+	}
+}
+
+// tableKey fingerprints the base and the modulus of a table.
+func tableKey(base, modulus *big.Int) [sha256.Size]byte {
+	h := sha256.New()
+	b := base.Bytes()
+	// the length prefix keeps (base, modulus) pairs with the same concatenation apart
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(b)))
+	h.Write(length[:])
+	h.Write(b)
+	h.Write(modulus.Bytes())
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// memSize returns the approximate memory used by the table entries in bytes.
+func (p *PreTable) memSize() int {
+	numWords := len(p.Modulus.Bits())
+	return p.TableSize * _W * numWords * (_W / 8)
+}
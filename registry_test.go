@@ -0,0 +1,62 @@
+package multiexp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTableRegistryEviction(t *testing.T) {
+	_, n, _ := getBenchParameters(1)
+	t1 := NewPrecomputeTable(big.NewInt(3), n, 4)
+	t2 := NewPrecomputeTable(big.NewInt(5), n, 4)
+	t3 := NewPrecomputeTable(big.NewInt(7), n, 4)
+
+	r := NewTableRegistry(2 * t1.memSize())
+	r.Register(t1)
+	r.Register(t2)
+	// t1 becomes the most recently used, so t2 is evicted by t3
+	if got, release := r.Acquire(t1.Base, n); got != t1 {
+		t.Fatalf("Acquire did not return the registered table")
+	} else {
+		release()
+	}
+	r.Register(t3)
+	if r.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", r.Len())
+	}
+	if got, release := r.Acquire(t2.Base, n); got != nil {
+		release()
+		t.Errorf("the least recently used table was not evicted")
+	}
+
+	// a table in use survives a cap below its size
+	got, release := r.Acquire(t3.Base, n)
+	r.SetMaxBytes(0)
+	if r.Len() != 1 {
+		t.Fatalf("Len() = %d, want only the acquired table", r.Len())
+	}
+	release()
+	release()
+	if r.Len() != 0 {
+		t.Errorf("the released table was not evicted")
+	}
+	if got != t3 {
+		t.Errorf("Acquire did not return the registered table")
+	}
+}
+
+func TestTableRegistryExp(t *testing.T) {
+	g, n, _ := getBenchParameters(1)
+	r := NewTableRegistry(defaultRegistryBytes)
+	r.Register(getBenchPrecomputeTable())
+	for _, y := range getDifferentBenchParameters(2) {
+		if got, want := r.Exp(g, y, n), new(big.Int).Exp(g, y, n); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for TableRegistry.Exp with a registered table")
+		}
+		// no table registered for this base
+		x := new(big.Int).Add(g, big1)
+		if got, want := r.Exp(x, y, n), new(big.Int).Exp(x, y, n); got.Cmp(want) != 0 {
+			t.Errorf("Wrong result for TableRegistry.Exp without a registered table")
+		}
+	}
+}